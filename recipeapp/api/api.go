@@ -1,81 +1,88 @@
 package api
 
 import (
-	"errors"
-	"log"
-	"recipeapp/client"
+	"fmt"
 	"recipeapp/cookie"
-	"recipeapp/database"
-	"recipeapp/models"
+	"recipeapp/metrics"
+	"recipeapp/planner"
+	"recipeapp/pricing"
 	"recipeapp/serverError"
-	"recipeapp/shoppinglist"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
-func GetRecipes(c *gin.Context) {
-	id, err := uuid.Parse(cookie.GetCookie(c))
-	if err != nil {
-		log.Println(err)
+func (h *Handlers) GetRecipes(c *gin.Context) {
+	ctx := c.Request.Context()
+	entry, ok := h.currentPlan(c)
+	if !ok {
 		return
 	}
-	db, err := database.GetDB()
-	if err != nil {
-		log.Fatal(err)
-	}
-	recipes, err := database.GetRecipesFromDBByUUID(db, id)
+	response, err := h.planResponse(ctx, cookie.GetUserID(c), c.Query("store"), entry.Meals, planner.Schedule(entry.Schedule))
 	if err != nil {
-		log.Println(err)
-		c.JSON(500, gin.H{
-			"error": "Internal server error"})
+		c.Error(serverError.Internal(err))
 		return
 	}
-	converter := shoppinglist.IngredientConverter{}
-	shoppingList := converter.ConvertMeals(recipes)
-	c.JSON(200, gin.H{
-		"recipe":        recipes,
-		"shopping_list": shoppingList,
-	})
+	c.JSON(200, response)
 }
 
-// Generates 7 new recipes and returning the as a JSON array
-func NewRecipes(c *gin.Context) {
-	recipes := []models.Meal{}
-	for i := 0; i < 7; i++ {
-		resp, err := client.NewRecipe()
-		if err != nil {
-			if errors.Is(err, serverError.BadInternalApiCall) {
-				c.JSON(503, gin.H{
-					"error": "Failed to fetch new recipe",
-				})
-				return
-			}
-			c.JSON(500, gin.H{
-				"error": "Internal server error",
-			})
-			return
-		}
-		// Filtering out unwanted categories
-		if resp.Meals[0].StrCategory != "Dessert" && resp.Meals[0].StrCategory != "Side" && resp.Meals[0].StrCategory != "Miscellaneous" && resp.Meals[0].StrCategory != "Starter" {
-			recipes = append(recipes, resp.Meals...)
-		} else {
-			i--
+// Generates 7 new recipes and returning the as a JSON array.
+// ?known=0.5 sets the share of favourites and well-rated meals in the plan,
+// ?mode=overlap selects meals that share as many ingredients as possible,
+// ?budget=60 keeps the estimated cost of the plan within the budget,
+// ?tags=quick,pasta only plans meals with one of the tags and ?exclude_tags=spicy none with these,
+// ?leftovers=2:1 eats the leftovers of day 1 on day 2 and ?eating_out=6,7 cooks nothing on these days,
+// ?max_per_category=2, ?max_per_area=2, ?max_per_protein=2, ?min_cuisines=4 and ?no_repeat_weeks=3 ask for variety,
+// ?seed=42 generates the plan from the cached meals only, the same seed always gives the same plan,
+// ?debug=true adds the meals that were rejected and why.
+func (h *Handlers) NewRecipes(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := cookie.GetUserID(c)
+	g, err := h.generatePlan(ctx, userID, c.Request.URL.Query(), time.Now())
+	if err != nil {
+		respondPlanError(c, err)
+		return
+	}
+	plan, prefs := g.plan, g.prefs
+	recipes := plan.Meals
+	for _, rejection := range plan.Rejected {
+		if planner.ExcludedCategory(rejection.StrCategory) {
+			metrics.CategoryRejections.WithLabelValues(rejection.StrCategory).Inc()
 		}
 	}
-	converter := shoppinglist.IngredientConverter{}
-	shoppingList := converter.ConvertMeals(recipes)
-	db, err := database.GetDB()
+	// The response is built before the plan is stored, a failure must not leave a cookie of a plan never shown
+	response, err := h.planResponse(ctx, userID, c.Query("store"), recipes, prefs.Schedule)
 	if err != nil {
-		log.Fatal(err)
+		c.Error(serverError.Internal(err))
+		return
 	}
-	id, err := database.CreateEntry(db, recipes)
+	id, err := h.savePlan(ctx, g.entry(userID), g.discovered...)
 	if err != nil {
-		log.Fatal(err)
+		c.Error(serverError.Internal(err))
+		return
 	}
 	cookie.SetCookie(c, id.String())
-	c.JSON(200, gin.H{
-		"recipe":        recipes,
-		"shopping_list": shoppingList,
-	})
+	if plan.Overlap != nil {
+		response["overlap_score"] = plan.Overlap
+	}
+	if warning := budgetWarning(prefs, response); warning != "" {
+		response["warnings"] = []string{warning}
+	}
+	if c.Query("debug") == "true" {
+		response["rejected"] = plan.Rejected
+		response["seed"] = g.seed
+	}
+	c.JSON(200, response)
+}
+
+// budgetWarning tells that a plan within the budget may cost more, as the budget counts ingredients without
+// a price as free. It is empty for plans without a budget or with all ingredients priced.
+func budgetWarning(prefs planner.Preferences, response gin.H) string {
+	estimate, ok := response["cost"].(pricing.Estimate)
+	if prefs.Budget <= 0 || !ok || len(estimate.Unpriced) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("The budget counts %d ingredients without a price as free, the plan may cost more: %s",
+		len(estimate.Unpriced), strings.Join(estimate.Unpriced, ", "))
 }
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"recipeapp/cookie"
+	"recipeapp/cooking"
+	"recipeapp/database"
+	"recipeapp/models"
+	"recipeapp/serverError"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCookingPlan returns the meals of the users plan split into steps with timers and ingredients
+func (h *Handlers) GetCookingPlan(c *gin.Context) {
+	entry, ok := h.currentPlan(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, gin.H{
+		"meals": cooking.FromMeals(entry.Meals),
+	})
+}
+
+// GetCookingMeal returns a single meal, own recipe or from TheMealDB, in cooking mode
+func (h *Handlers) GetCookingMeal(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	if database.IsUserRecipeID(id) {
+		meal, err := h.Plans.GetUserRecipe(ctx, cookie.GetUserID(c), id)
+		if err != nil {
+			respondUserRecipeError(c, err)
+			return
+		}
+		c.JSON(200, cooking.FromMeal(meal))
+		return
+	}
+	meals, err := h.resolveMeals(ctx, []models.Meal{}, []string{id})
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	if len(meals) == 0 {
+		c.Error(serverError.New(serverError.CodeNotFound, "Recipe not found"))
+		return
+	}
+	c.JSON(200, cooking.FromMeal(meals[0]))
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"recipeapp/serverError"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegeneratePlan generates a plan of the user again from its stored seed, options, candidates and discoveries and
+// compares it with the stored plan. catalogue_changed tells that the candidates or the preferences of the user differ
+// from the original generation, the plans may differ then. Plans from before seeds were stored answer 409.
+func (h *Handlers) RegeneratePlan(c *gin.Context) {
+	ctx := c.Request.Context()
+	entry, ok := h.loadUserEntry(c)
+	if !ok {
+		return
+	}
+	if entry.Seed == nil {
+		c.Error(serverError.New(serverError.CodeConflict, "The plan was not generated with a seed"))
+		return
+	}
+	g, err := h.regeneratePlan(ctx, entry)
+	if err != nil {
+		respondPlanError(c, err)
+		return
+	}
+	stored := make([]string, 0, len(entry.Meals))
+	for _, meal := range entry.Meals {
+		stored = append(stored, meal.IdMeal)
+	}
+	regenerated := make([]string, 0, len(g.plan.Meals))
+	for _, meal := range g.plan.Meals {
+		regenerated = append(regenerated, meal.IdMeal)
+	}
+	c.JSON(200, gin.H{
+		"id":                entry.EntryUUID,
+		"seed":              *entry.Seed,
+		"options":           entry.Options,
+		"stored":            stored,
+		"regenerated":       regenerated,
+		"identical":         slices.Equal(stored, regenerated),
+		"catalogue_changed": g.fingerprint != entry.Fingerprint,
+		"rejected":          g.plan.Rejected,
+	})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"recipeapp/cookie"
+	"recipeapp/database"
+	"recipeapp/diet"
+	"recipeapp/serverError"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetDietProfile returns the dietary restrictions of the user
+func (h *Handlers) GetDietProfile(c *gin.Context) {
+	ctx := c.Request.Context()
+	profile, err := h.loadDietProfile(ctx, cookie.GetUserID(c))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, profile)
+}
+
+// PutDietProfile replaces the dietary restrictions of the user
+func (h *Handlers) PutDietProfile(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		Diets    []string `json:"diets"`
+		Excluded []string `json:"excluded_ingredients"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	entry := database.DietProfile{
+		UserUUID:            cookie.GetUserID(c),
+		Diets:               database.StringsJSON{},
+		ExcludedIngredients: database.StringsJSON{},
+	}
+	for _, name := range req.Diets {
+		d, ok := diet.ParseDiet(name)
+		if !ok {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Unknown diet: "+name))
+			return
+		}
+		entry.Diets = append(entry.Diets, string(d))
+	}
+	for _, ingredient := range req.Excluded {
+		if ingredient = strings.TrimSpace(ingredient); ingredient != "" {
+			entry.ExcludedIngredients = append(entry.ExcludedIngredients, ingredient)
+		}
+	}
+	if err := h.Plans.SaveDietProfile(ctx, entry); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, toDietProfile(entry))
+}
+
+// loadDietProfile returns the diet profile of a user
+func (h *Handlers) loadDietProfile(ctx context.Context, user uuid.UUID) (diet.Profile, error) {
+	entry, err := h.Plans.GetDietProfile(ctx, user)
+	if err != nil {
+		return diet.Profile{}, err
+	}
+	return toDietProfile(entry), nil
+}
+
+func toDietProfile(entry database.DietProfile) diet.Profile {
+	profile := diet.Profile{
+		Diets:    []diet.Diet{},
+		Excluded: []string{},
+	}
+	for _, name := range entry.Diets {
+		if d, ok := diet.ParseDiet(name); ok {
+			profile.Diets = append(profile.Diets, d)
+		}
+	}
+	profile.Excluded = append(profile.Excluded, entry.ExcludedIngredients...)
+	return profile
+}
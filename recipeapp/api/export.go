@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"recipeapp/cookie"
+	"recipeapp/database"
+	"recipeapp/export"
+	"recipeapp/models"
+	"recipeapp/planner"
+	"recipeapp/serverError"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const defaultDinnerTime = 18 * time.Hour
+
+// ExportShoppingList renders the shopping list of the users plan as text, markdown, csv, json or html
+func (h *Handlers) ExportShoppingList(c *gin.Context) {
+	ctx := c.Request.Context()
+	format, ok := export.ParseFormat(c.DefaultQuery("format", string(export.FormatText)))
+	if !ok {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Unknown export format: "+c.Query("format")))
+		return
+	}
+	entry, ok := h.currentPlan(c)
+	if !ok {
+		return
+	}
+	recipes := []models.Meal(entry.Meals)
+	userID := cookie.GetUserID(c)
+	order, err := h.loadAisleOrder(ctx, userID, c.Query("store"))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	mapper, err := h.loadSectionMapper(ctx, userID)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	list := h.ShoppingLists.Build(recipes, planner.Schedule(entry.Schedule).Servings(recipes), mapper, order)
+	body, err := export.ShoppingList(format, recipes, list.Items)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	if format != export.FormatHTML {
+		c.Header("Content-Disposition", "attachment; filename=shopping-list."+export.FileExtensions[format])
+	}
+	c.Data(200, export.ContentTypes[format], body)
+}
+
+// ExportCalendar renders the users plan as an iCalendar file, see respondCalendar
+func (h *Handlers) ExportCalendar(c *gin.Context) {
+	entry, ok := h.currentPlan(c)
+	if !ok {
+		return
+	}
+	respondCalendar(c, entry)
+}
+
+// ExportPlanCalendar serves the plan of the :id parameter as iCalendar feed, see respondCalendar.
+// Calendar apps subscribe to it without the cookies of the page, the unguessable plan id is the token.
+func (h *Handlers) ExportPlanCalendar(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(serverError.New(serverError.CodeNotFound, "Plan not found"))
+		return
+	}
+	entry, err := h.Plans.GetEntryByUUID(ctx, id)
+	if errors.Is(err, database.ErrNotFound) {
+		c.Error(serverError.New(serverError.CodeNotFound, "Plan not found"))
+		return
+	}
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	respondCalendar(c, entry)
+}
+
+// calendarURL is the path of the iCalendar feed of a plan
+func calendarURL(id uuid.UUID) string {
+	return "/api/plan/" + id.String() + "/calendar.ics"
+}
+
+// respondCalendar answers with the plan as an iCalendar feed with one event per dinner.
+// The first dinner is on the first day of the plan's week, on the day it was generated for plans without one,
+// unless ?start=YYYY-MM-DD is given. ?time=HH:MM sets the dinner time.
+func respondCalendar(c *gin.Context, entry database.RecipesEntry) {
+	dinner := defaultDinnerTime
+	if t := c.Query("time"); t != "" {
+		parsed, err := time.Parse("15:04", t)
+		if err != nil {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid time, expected HH:MM"))
+			return
+		}
+		dinner = time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute
+	}
+	start := entry.WeekStart
+	if start.IsZero() {
+		start = entry.CreatedAt
+	}
+	if start.IsZero() {
+		start = time.Now()
+	}
+	start = start.Local()
+	if s := c.Query("start"); s != "" {
+		var err error
+		start, err = time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid start date, expected YYYY-MM-DD"))
+			return
+		}
+	}
+	days := planner.Schedule(entry.Schedule).Days(entry.Meals)
+	body := export.ICal(entry.EntryUUID.String(), days, start, dinner, time.Now())
+	c.Header("Content-Disposition", "attachment; filename=plan.ics")
+	c.Data(200, export.ICalContentType, body)
+}
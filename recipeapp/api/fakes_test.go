@@ -0,0 +1,511 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"recipeapp/client"
+	"recipeapp/database"
+	"recipeapp/models"
+	"recipeapp/pricing"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeStore keeps everything a PlanStore stores in maps
+type fakeStore struct {
+	entries     map[uuid.UUID]database.RecipesEntry
+	cached      map[string]models.Meal
+	ownRecipes  map[string]ownRecipe
+	ratings     map[string]database.MealRating // user id + meal id
+	templates   map[uuid.UUID]database.PlanTemplate
+	rules       map[uuid.UUID]database.RecurringRule
+	diets       map[uuid.UUID]database.DietProfile
+	pantries    map[uuid.UUID][]string
+	prices      map[string]pricing.Price // ingredient + unit
+	aisleOrders map[string][]string      // user id + store
+	sections    map[uuid.UUID]map[string]string
+}
+
+// ownRecipe is an own recipe of the fake store with its meal
+type ownRecipe struct {
+	database.UserRecipe
+	meal models.Meal
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		entries:     make(map[uuid.UUID]database.RecipesEntry),
+		cached:      make(map[string]models.Meal),
+		ownRecipes:  make(map[string]ownRecipe),
+		ratings:     make(map[string]database.MealRating),
+		templates:   make(map[uuid.UUID]database.PlanTemplate),
+		rules:       make(map[uuid.UUID]database.RecurringRule),
+		diets:       make(map[uuid.UUID]database.DietProfile),
+		pantries:    make(map[uuid.UUID][]string),
+		prices:      make(map[string]pricing.Price),
+		aisleOrders: make(map[string][]string),
+		sections:    make(map[uuid.UUID]map[string]string),
+	}
+}
+
+// meal returns the meal as it is stored now, plans show the latest version of their meals
+func (s *fakeStore) meal(meal models.Meal) models.Meal {
+	if own, ok := s.ownRecipes[meal.IdMeal]; ok {
+		return own.meal
+	}
+	if cached, ok := s.cached[meal.IdMeal]; ok {
+		return cached
+	}
+	return meal
+}
+
+func (s *fakeStore) withMeals(entry database.RecipesEntry) database.RecipesEntry {
+	meals := make(database.MealsJSON, 0, len(entry.Meals))
+	for _, meal := range entry.Meals {
+		meals = append(meals, s.meal(meal))
+	}
+	entry.Meals = meals
+	return entry
+}
+
+func (s *fakeStore) CreateEntry(ctx context.Context, entry database.RecipesEntry) (uuid.UUID, error) {
+	entry.EntryUUID = uuid.New()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	s.entries[entry.EntryUUID] = entry
+	return entry.EntryUUID, nil
+}
+
+func (s *fakeStore) GetUserEntries(ctx context.Context, user uuid.UUID) ([]database.RecipesEntry, error) {
+	var entries []database.RecipesEntry
+	for _, entry := range s.entries {
+		if entry.UserUUID == user {
+			entries = append(entries, s.withMeals(entry))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.After(entries[j].CreatedAt) })
+	return entries, nil
+}
+
+func (s *fakeStore) GetEntryByUUID(ctx context.Context, id uuid.UUID) (database.RecipesEntry, error) {
+	entry, ok := s.entries[id]
+	if !ok {
+		return database.RecipesEntry{}, database.ErrNotFound
+	}
+	return s.withMeals(entry), nil
+}
+
+func (s *fakeStore) UpdateEntry(ctx context.Context, entry database.RecipesEntry) error {
+	if _, ok := s.entries[entry.EntryUUID]; !ok {
+		return database.ErrNotFound
+	}
+	s.entries[entry.EntryUUID] = entry
+	return nil
+}
+
+func (s *fakeStore) CacheMeals(ctx context.Context, meals []models.Meal) error {
+	for _, meal := range meals {
+		if !database.IsUserRecipeID(meal.IdMeal) {
+			s.cached[meal.IdMeal] = meal
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) GetCachedMeals(ctx context.Context, ids []string) (map[string]models.Meal, error) {
+	meals := make(map[string]models.Meal)
+	for _, id := range ids {
+		if meal, ok := s.cached[id]; ok {
+			meals[id] = meal
+		}
+	}
+	return meals, nil
+}
+
+func (s *fakeStore) GetAllCachedMeals(ctx context.Context) ([]models.Meal, error) {
+	meals := make([]models.Meal, 0, len(s.cached))
+	for _, meal := range s.cached {
+		meals = append(meals, meal)
+	}
+	sort.Slice(meals, func(i, j int) bool { return meals[i].IdMeal < meals[j].IdMeal })
+	return meals, nil
+}
+
+// SearchMeals matches the text against the names of the cached meals only
+func (s *fakeStore) SearchMeals(ctx context.Context, query database.SearchQuery) ([]models.Meal, int, error) {
+	all, _ := s.GetAllCachedMeals(ctx)
+	var meals []models.Meal
+	for _, meal := range all {
+		if strings.Contains(strings.ToLower(meal.StrMeal), strings.ToLower(query.Text)) {
+			meals = append(meals, meal)
+		}
+	}
+	total := len(meals)
+	if query.Offset < len(meals) {
+		meals = meals[query.Offset:]
+	} else {
+		meals = nil
+	}
+	if query.Limit > 0 && len(meals) > query.Limit {
+		meals = meals[:query.Limit]
+	}
+	return meals, total, nil
+}
+
+// visibleMeals returns the cached meals and the own recipes of the user
+func (s *fakeStore) visibleMeals(ctx context.Context, user uuid.UUID) []models.Meal {
+	meals, _ := s.GetAllCachedMeals(ctx)
+	own, _ := s.GetUserRecipes(ctx, user)
+	return append(meals, own...)
+}
+
+func (s *fakeStore) GetTagCounts(ctx context.Context, user uuid.UUID) ([]database.TagCount, error) {
+	counts := make(map[string]int)
+	for _, meal := range s.visibleMeals(ctx, user) {
+		for _, tag := range meal.Tags() {
+			counts[tag]++
+		}
+	}
+	result := []database.TagCount{}
+	for name, count := range counts {
+		result = append(result, database.TagCount{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (s *fakeStore) GetMealIDsByTag(ctx context.Context, user uuid.UUID, tag string) ([]string, error) {
+	ids := []string{}
+	for _, meal := range s.visibleMeals(ctx, user) {
+		for _, t := range meal.Tags() {
+			if t == models.NormalizeTag(tag) {
+				ids = append(ids, meal.IdMeal)
+				break
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *fakeStore) CreateUserRecipe(ctx context.Context, owner uuid.UUID, meal models.Meal) (models.Meal, error) {
+	meal.IdMeal = database.UserRecipePrefix + uuid.NewString()
+	s.ownRecipes[meal.IdMeal] = ownRecipe{UserRecipe: database.UserRecipe{IdMeal: meal.IdMeal, OwnerUUID: owner,
+		CreatedAt: time.Now()}, meal: meal}
+	return meal, nil
+}
+
+func (s *fakeStore) GetUserRecipes(ctx context.Context, owner uuid.UUID) ([]models.Meal, error) {
+	var recipes []ownRecipe
+	for _, recipe := range s.ownRecipes {
+		if recipe.OwnerUUID == owner {
+			recipes = append(recipes, recipe)
+		}
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].CreatedAt.Before(recipes[j].CreatedAt) })
+	meals := make([]models.Meal, 0, len(recipes))
+	for _, recipe := range recipes {
+		meals = append(meals, recipe.meal)
+	}
+	return meals, nil
+}
+
+func (s *fakeStore) GetUserRecipe(ctx context.Context, owner uuid.UUID, id string) (models.Meal, error) {
+	recipe, ok := s.ownRecipes[id]
+	if !ok || recipe.OwnerUUID != owner {
+		return models.Meal{}, database.ErrNotFound
+	}
+	return recipe.meal, nil
+}
+
+func (s *fakeStore) UpdateUserRecipe(ctx context.Context, owner uuid.UUID, meal models.Meal) error {
+	recipe, ok := s.ownRecipes[meal.IdMeal]
+	if !ok || recipe.OwnerUUID != owner {
+		return database.ErrNotFound
+	}
+	recipe.meal = meal
+	s.ownRecipes[meal.IdMeal] = recipe
+	return nil
+}
+
+func (s *fakeStore) DeleteUserRecipe(ctx context.Context, owner uuid.UUID, id string) error {
+	if _, err := s.GetUserRecipe(ctx, owner, id); err != nil {
+		return err
+	}
+	delete(s.ownRecipes, id)
+	return nil
+}
+
+func (s *fakeStore) GetRatings(ctx context.Context, user uuid.UUID) ([]database.MealRating, error) {
+	var ratings []database.MealRating
+	for _, rating := range s.ratings {
+		if rating.UserUUID == user {
+			ratings = append(ratings, rating)
+		}
+	}
+	sort.Slice(ratings, func(i, j int) bool { return ratings[i].IdMeal < ratings[j].IdMeal })
+	return ratings, nil
+}
+
+func (s *fakeStore) GetRating(ctx context.Context, user uuid.UUID, id string) (database.MealRating, error) {
+	if rating, ok := s.ratings[user.String()+id]; ok {
+		return rating, nil
+	}
+	return database.MealRating{UserUUID: user, IdMeal: id}, nil
+}
+
+func (s *fakeStore) SaveRating(ctx context.Context, rating database.MealRating) error {
+	s.ratings[rating.UserUUID.String()+rating.IdMeal] = rating
+	return nil
+}
+
+func (s *fakeStore) DeleteRating(ctx context.Context, user uuid.UUID, id string) error {
+	delete(s.ratings, user.String()+id)
+	return nil
+}
+
+func (s *fakeStore) CreateTemplate(ctx context.Context, template database.PlanTemplate) (database.PlanTemplate, error) {
+	template.TemplateUUID = uuid.New()
+	s.templates[template.TemplateUUID] = template
+	return template, nil
+}
+
+func (s *fakeStore) GetTemplates(ctx context.Context, owner uuid.UUID) ([]database.PlanTemplate, error) {
+	var templates []database.PlanTemplate
+	for _, template := range s.templates {
+		if template.OwnerUUID == owner {
+			templates = append(templates, s.templateWithMeals(template))
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+func (s *fakeStore) GetTemplate(ctx context.Context, owner uuid.UUID, id uuid.UUID) (database.PlanTemplate, error) {
+	template, ok := s.templates[id]
+	if !ok || template.OwnerUUID != owner {
+		return database.PlanTemplate{}, database.ErrNotFound
+	}
+	return s.templateWithMeals(template), nil
+}
+
+// templateWithMeals returns the template with the latest version of its meals, like plans
+func (s *fakeStore) templateWithMeals(template database.PlanTemplate) database.PlanTemplate {
+	template.Meals = s.withMeals(database.RecipesEntry{Meals: template.Meals}).Meals
+	return template
+}
+
+func (s *fakeStore) DeleteTemplate(ctx context.Context, owner uuid.UUID, id uuid.UUID) error {
+	if _, err := s.GetTemplate(ctx, owner, id); err != nil {
+		return err
+	}
+	delete(s.templates, id)
+	return nil
+}
+
+func (s *fakeStore) GetRecurringRules(ctx context.Context) ([]database.RecurringRule, error) {
+	var rules []database.RecurringRule
+	for _, rule := range s.rules {
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (s *fakeStore) GetRecurringRule(ctx context.Context, user uuid.UUID) (database.RecurringRule, error) {
+	rule, ok := s.rules[user]
+	if !ok {
+		return database.RecurringRule{}, database.ErrNotFound
+	}
+	return rule, nil
+}
+
+func (s *fakeStore) SaveRecurringRule(ctx context.Context, rule database.RecurringRule) error {
+	rule.UpdatedAt = time.Now()
+	s.rules[rule.UserUUID] = rule
+	return nil
+}
+
+func (s *fakeStore) DeleteRecurringRule(ctx context.Context, user uuid.UUID) error {
+	if _, ok := s.rules[user]; !ok {
+		return database.ErrNotFound
+	}
+	delete(s.rules, user)
+	return nil
+}
+
+func (s *fakeStore) ClaimRecurringRule(ctx context.Context, user uuid.UUID, slot, run time.Time) (bool, error) {
+	rule, ok := s.rules[user]
+	if !ok || !rule.LastRun.Before(slot) {
+		return false, nil
+	}
+	rule.LastRun = run
+	s.rules[user] = rule
+	return true, nil
+}
+
+func (s *fakeStore) ReleaseRecurringRule(ctx context.Context, user uuid.UUID, run, lastRun time.Time) error {
+	rule, ok := s.rules[user]
+	if !ok || !rule.LastRun.Equal(run) {
+		return nil
+	}
+	rule.LastRun = lastRun
+	s.rules[user] = rule
+	return nil
+}
+
+func (s *fakeStore) GetDietProfile(ctx context.Context, user uuid.UUID) (database.DietProfile, error) {
+	if profile, ok := s.diets[user]; ok {
+		return profile, nil
+	}
+	return database.DietProfile{UserUUID: user}, nil
+}
+
+func (s *fakeStore) SaveDietProfile(ctx context.Context, profile database.DietProfile) error {
+	s.diets[profile.UserUUID] = profile
+	return nil
+}
+
+func (s *fakeStore) GetPantry(ctx context.Context, user uuid.UUID) ([]string, error) {
+	return s.pantries[user], nil
+}
+
+func (s *fakeStore) SavePantry(ctx context.Context, user uuid.UUID, ingredients []string) error {
+	s.pantries[user] = ingredients
+	return nil
+}
+
+func (s *fakeStore) GetPrices(ctx context.Context) ([]pricing.Price, error) {
+	prices := make([]pricing.Price, 0, len(s.prices))
+	for _, price := range s.prices {
+		prices = append(prices, price)
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Ingredient+prices[i].Unit < prices[j].Ingredient+prices[j].Unit
+	})
+	return prices, nil
+}
+
+func (s *fakeStore) SavePrices(ctx context.Context, prices []pricing.Price) error {
+	for _, price := range prices {
+		s.prices[price.Ingredient+"|"+price.Unit] = price
+	}
+	return nil
+}
+
+func (s *fakeStore) DeletePrices(ctx context.Context, ingredient string, unit string) error {
+	for key, price := range s.prices {
+		if price.Ingredient == ingredient && (unit == "" || price.Unit == unit) {
+			delete(s.prices, key)
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) GetAisleOrder(ctx context.Context, user uuid.UUID, store string) ([]string, error) {
+	return s.aisleOrders[user.String()+store], nil
+}
+
+func (s *fakeStore) SaveAisleOrder(ctx context.Context, user uuid.UUID, store string, aisleOrder []string) error {
+	s.aisleOrders[user.String()+store] = aisleOrder
+	return nil
+}
+
+func (s *fakeStore) GetIngredientSections(ctx context.Context, user uuid.UUID) (map[string]string, error) {
+	sections := make(map[string]string, len(s.sections[user]))
+	for ingredient, section := range s.sections[user] {
+		sections[ingredient] = section
+	}
+	return sections, nil
+}
+
+func (s *fakeStore) SaveIngredientSection(ctx context.Context, user uuid.UUID, ingredient string, section string) error {
+	if s.sections[user] == nil {
+		s.sections[user] = make(map[string]string)
+	}
+	s.sections[user][ingredient] = section
+	return nil
+}
+
+func (s *fakeStore) DeleteIngredientSection(ctx context.Context, user uuid.UUID, ingredient string) error {
+	delete(s.sections[user], ingredient)
+	return nil
+}
+
+// errNoPage is returned for pages the fake source does not know
+var errNoPage = errors.New("page not found")
+
+// fakeSource serves the meals of a fixed catalogue in turn as random meals and the pages by their URL
+type fakeSource struct {
+	meals []models.Meal
+	pages map[string][]byte
+	err   error // returned by every call if set
+	calls int
+}
+
+func (s *fakeSource) NewRecipe(ctx context.Context) (*client.Response, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(s.meals) == 0 {
+		return &client.Response{}, nil
+	}
+	meal := s.meals[(s.calls-1)%len(s.meals)]
+	return &client.Response{Meals: []models.Meal{meal}}, nil
+}
+
+func (s *fakeSource) LookupMeal(ctx context.Context, id string) (*client.Response, error) {
+	return s.filter(func(meal models.Meal) bool { return meal.IdMeal == id })
+}
+
+func (s *fakeSource) FilterByIngredient(ctx context.Context, ingredient string) (*client.Response, error) {
+	return s.filter(func(meal models.Meal) bool {
+		for _, i := range meal.Ingredients() {
+			if strings.EqualFold(i.Name, ingredient) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (s *fakeSource) SearchMeals(ctx context.Context, name string) (*client.Response, error) {
+	return s.filter(func(meal models.Meal) bool {
+		return strings.Contains(strings.ToLower(meal.StrMeal), strings.ToLower(name))
+	})
+}
+
+func (s *fakeSource) FetchPage(ctx context.Context, pageURL string) ([]byte, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	page, ok := s.pages[pageURL]
+	if !ok {
+		return nil, errNoPage
+	}
+	return page, nil
+}
+
+func (s *fakeSource) filter(match func(meal models.Meal) bool) (*client.Response, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	resp := &client.Response{}
+	for _, meal := range s.meals {
+		if match(meal) {
+			resp.Meals = append(resp.Meals, meal)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,345 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/url"
+	"recipeapp/database"
+	"recipeapp/diet"
+	"recipeapp/logging"
+	"recipeapp/models"
+	"recipeapp/planner"
+	"recipeapp/serverError"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// optionError is an invalid plan option, it is answered with 400
+type optionError struct {
+	message string
+}
+
+func (e optionError) Error() string {
+	return e.message
+}
+
+// minLocalCandidates is the number of cached candidates from which plans are mostly generated from the cache
+const minLocalCandidates = 3 * planner.PlanSize
+
+// remoteDiscoveries is the number of meals still discovered at TheMealDB for plans from the cache,
+// without them the cache, which only grows by planned meals, would freeze
+const remoteDiscoveries = 2
+
+// generation is a generated plan with what is needed to generate it again
+type generation struct {
+	plan        planner.Plan
+	prefs       planner.Preferences
+	seed        int64
+	options     string        // encoded plan options without the seed
+	fingerprint string        // hash of the candidates and preferences the plan was generated from
+	discovered  []models.Meal // meals discovered at TheMealDB, in order
+}
+
+// entry returns the plan as entry of the user with the seed, candidates and discoveries to generate it again
+func (g generation) entry(user uuid.UUID) database.RecipesEntry {
+	entry := database.RecipesEntry{
+		UserUUID:    user,
+		Meals:       g.plan.Meals,
+		Schedule:    database.ScheduleJSON(g.prefs.Schedule),
+		Seed:        &g.seed,
+		Options:     g.options,
+		Fingerprint: g.fingerprint,
+		Candidates:  database.StringsJSON{},
+		Discoveries: database.StringsJSON{},
+	}
+	for _, meal := range g.prefs.Candidates {
+		entry.Candidates = append(entry.Candidates, meal.IdMeal)
+	}
+	for _, meal := range g.discovered {
+		entry.Discoveries = append(entry.Discoveries, meal.IdMeal)
+	}
+	return entry
+}
+
+// generatePlan generates a plan for the user with the options /api/newrecipes takes as query.
+// With a seed option the plan is generated from the cached meals only, the same seed, options and
+// candidates always give the same plan, as do plans with included tags, which are drawn from the cache only.
+// Without a seed and tags, meals are discovered at TheMealDB while the cache holds
+// fewer than minLocalCandidates candidates, and only remoteDiscoveries of them once it holds more.
+// Plans without a seed option get a random one. Recent meals are looked up as of now.
+func (h *Handlers) generatePlan(ctx context.Context, user uuid.UUID, options url.Values, now time.Time) (generation, error) {
+	return h.generate(ctx, user, options, now, nil)
+}
+
+// regeneratePlan generates a stored plan again from its seed and options, drawing from the candidates and
+// discoveries of the original generation instead of the current cache and TheMealDB
+func (h *Handlers) regeneratePlan(ctx context.Context, entry database.RecipesEntry) (generation, error) {
+	options, err := url.ParseQuery(entry.Options)
+	if err != nil {
+		return generation{}, err
+	}
+	return h.generate(ctx, entry.UserUUID, options, entry.CreatedAt, &entry)
+}
+
+// generate generates a plan, a new one or, if replay is set, the stored plan again
+func (h *Handlers) generate(ctx context.Context, user uuid.UUID, options url.Values, now time.Time,
+	replay *database.RecipesEntry) (generation, error) {
+	g := generation{}
+	prefs, err := h.loadPreferences(ctx, user)
+	if err != nil {
+		return g, err
+	}
+	profile, err := h.loadDietProfile(ctx, user)
+	if err != nil {
+		return g, err
+	}
+	if !profile.IsEmpty() {
+		prefs.Filter = profile.Check
+	}
+	if err := h.applyPlanOptions(ctx, &prefs, options); err != nil {
+		return g, err
+	}
+	if prefs.Variety.RecentWeeks > 0 {
+		prefs.Variety.Recent, err = h.recentMeals(ctx, user, prefs.Variety.RecentWeeks, now)
+		if err != nil {
+			return g, err
+		}
+	}
+
+	seeded := options.Get("seed") != ""
+	switch {
+	case replay != nil:
+		g.seed = *replay.Seed
+		prefs.Candidates, err = h.snapshotMeals(ctx, replay.Candidates)
+	case seeded:
+		g.seed, err = strconv.ParseInt(options.Get("seed"), 10, 64)
+		if err != nil {
+			return g, optionError{"seed must be a whole number"}
+		}
+		prefs.Candidates, err = h.Plans.GetAllCachedMeals(ctx)
+	default:
+		g.seed = rand.Int63()
+		prefs.Candidates, err = h.Plans.GetAllCachedMeals(ctx)
+	}
+	if err != nil {
+		return g, err
+	}
+
+	p := planner.Deterministic(g.seed)
+	random := func() (models.Meal, error) { return h.randomMeal(ctx) }
+	if replay != nil {
+		discoveries, err := h.snapshotMeals(ctx, replay.Discoveries)
+		if err != nil {
+			return g, err
+		}
+		random = replayedMeals(discoveries)
+	}
+	record := func() (models.Meal, error) {
+		meal, err := random()
+		if err == nil {
+			g.discovered = append(g.discovered, meal)
+		}
+		return meal, err
+	}
+	switch {
+	case replay != nil:
+		// the plan was discovered at TheMealDB entirely if the candidates were too few and it discovered anything,
+		// otherwise the discoveries are replayed as remote discoveries, which fall back to the candidates when used up
+		p.Random = record
+		if len(replay.Discoveries) > 0 && len(replay.Candidates) < minLocalCandidates {
+			p.Local = false
+		} else {
+			p.RemoteDiscoveries = remoteDiscoveries
+		}
+	case seeded || len(prefs.IncludeTags) > 0:
+		// Random meals rarely have tags, plans with tags are drawn from the cache
+	case len(prefs.Candidates) >= minLocalCandidates:
+		p.Random = record
+		p.RemoteDiscoveries = remoteDiscoveries
+	default:
+		p.Random = record
+		p.Local = false
+	}
+	settings := url.Values{}
+	for key, values := range options {
+		if key != "seed" && key != "store" && key != "debug" {
+			settings[key] = values
+		}
+	}
+	g.options = settings.Encode()
+	g.fingerprint = fingerprint(prefs, profile, g.options)
+
+	if options.Get("mode") == "overlap" {
+		g.plan, err = p.GenerateOverlap(prefs)
+	} else {
+		g.plan, err = p.Generate(prefs)
+	}
+	g.prefs = prefs
+	return g, err
+}
+
+// snapshotMeals loads the cached meals of a stored generation in their order, meals no longer cached are left out
+func (h *Handlers) snapshotMeals(ctx context.Context, ids []string) ([]models.Meal, error) {
+	cached, err := h.Plans.GetCachedMeals(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	meals := make([]models.Meal, 0, len(ids))
+	for _, id := range ids {
+		if meal, ok := cached[id]; ok {
+			meals = append(meals, meal)
+		}
+	}
+	return meals, nil
+}
+
+// replayedMeals returns the meals one after the other in place of TheMealDB, and fails once they are used up
+// like TheMealDB failed for the original generation
+func replayedMeals(meals []models.Meal) func() (models.Meal, error) {
+	return func() (models.Meal, error) {
+		if len(meals) == 0 {
+			return models.Meal{}, serverError.BadInternalApiCall
+		}
+		meal := meals[0]
+		meals = meals[1:]
+		return meal, nil
+	}
+}
+
+// fingerprint hashes everything besides the seed a generation depends on, to tell whether a regenerated plan
+// had the same input
+func fingerprint(prefs planner.Preferences, profile diet.Profile, options string) string {
+	blocked := make([]string, 0, len(prefs.Blocked))
+	for id := range prefs.Blocked {
+		blocked = append(blocked, id)
+	}
+	recent := make([]string, 0, len(prefs.Variety.Recent))
+	for id := range prefs.Variety.Recent {
+		recent = append(recent, id)
+	}
+	slices.Sort(blocked)
+	slices.Sort(recent)
+	known := slices.Clone(prefs.KnownGood)
+	slices.SortFunc(known, func(a, b planner.WeightedMeal) int { return strings.Compare(a.Meal.IdMeal, b.Meal.IdMeal) })
+
+	data, err := json.Marshal([]any{options, profile, blocked, recent, known, prefs.Candidates})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// applyPlanOptions sets the preferences from the plan options, returns an optionError for invalid ones
+func (h *Handlers) applyPlanOptions(ctx context.Context, prefs *planner.Preferences, options url.Values) error {
+	if mode := options.Get("mode"); mode != "" && mode != "random" && mode != "overlap" {
+		return optionError{"mode must be random or overlap"}
+	}
+	if known := options.Get("known"); known != "" {
+		ratio, err := strconv.ParseFloat(known, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return optionError{"known must be a number between 0 and 1"}
+		}
+		prefs.KnownGoodRatio = ratio
+	}
+	if budget := options.Get("budget"); budget != "" {
+		var err error
+		prefs.Budget, err = strconv.ParseFloat(budget, 64)
+		if err != nil || prefs.Budget <= 0 {
+			return optionError{"budget must be a positive number"}
+		}
+		catalogue, err := h.loadCatalogue(ctx)
+		if err != nil {
+			return err
+		}
+		prefs.Cost = catalogue.Cost
+	}
+	if options.Get("leftovers") != "" || options.Get("eating_out") != "" {
+		schedule, err := planner.ParseSchedule(options.Get("leftovers"), options.Get("eating_out"))
+		if err != nil {
+			return optionError{err.Error()}
+		}
+		prefs.Schedule = schedule
+	}
+	prefs.IncludeTags = models.ParseTags(options.Get("tags"))
+	prefs.ExcludeTags = models.ParseTags(options.Get("exclude_tags"))
+
+	limits := []struct {
+		name  string
+		value *int
+	}{
+		{"max_per_category", &prefs.Variety.MaxPerCategory},
+		{"max_per_area", &prefs.Variety.MaxPerArea},
+		{"max_per_protein", &prefs.Variety.MaxPerProtein},
+		{"min_cuisines", &prefs.Variety.MinCuisines},
+		{"no_repeat_weeks", &prefs.Variety.RecentWeeks},
+	}
+	for _, limit := range limits {
+		if value := options.Get(limit.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return optionError{limit.name + " must be a positive whole number"}
+			}
+			*limit.value = n
+		}
+	}
+	return nil
+}
+
+// recentMeals returns the ids of the meals in the plans of the user for the weeks before now,
+// plans created after now are left out so older generations can be repeated
+func (h *Handlers) recentMeals(ctx context.Context, user uuid.UUID, weeks int, now time.Time) (map[string]bool, error) {
+	entries, err := h.Plans.GetUserEntries(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	since := now.AddDate(0, 0, -7*weeks)
+	recent := make(map[string]bool)
+	for _, entry := range entries {
+		start := entry.WeekStart
+		if start.IsZero() {
+			start = entry.CreatedAt
+		}
+		if start.Before(since) || !entry.CreatedAt.Before(now) {
+			continue
+		}
+		for _, meal := range entry.Meals {
+			recent[meal.IdMeal] = true
+		}
+	}
+	return recent, nil
+}
+
+// savePlan caches the meals of a plan and the meals discovered for it, so the plan can be generated again, and stores it
+func (h *Handlers) savePlan(ctx context.Context, entry database.RecipesEntry, discovered ...models.Meal) (uuid.UUID, error) {
+	if err := h.Plans.CacheMeals(ctx, append(slices.Clone(entry.Meals), discovered...)); err != nil {
+		logging.FromContext(ctx).Warn("caching meals failed", "error", err)
+	}
+	return h.Plans.CreateEntry(ctx, entry)
+}
+
+// respondPlanError answers a failed plan generation
+func respondPlanError(c *gin.Context, err error) {
+	var invalid optionError
+	switch {
+	case errors.As(err, &invalid):
+		c.Error(serverError.New(serverError.CodeInvalidRequest, invalid.Error()))
+	case errors.Is(err, planner.ErrOverBudget) || errors.Is(err, planner.ErrVariety):
+		c.Error(serverError.New(serverError.CodeUnprocessable, err.Error()))
+	case errors.Is(err, planner.ErrTooFewTagged):
+		c.Error(serverError.New(serverError.CodeUnprocessable, "Not enough cached meals with the tags to fill the plan"))
+	case errors.Is(err, serverError.BadInternalApiCall) || errors.Is(err, planner.ErrNotEnoughMeals):
+		c.Error(serverError.Wrap(serverError.CodeUnavailable, "Failed to fetch new recipe", err))
+	default:
+		c.Error(serverError.Internal(err))
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"recipeapp/client"
+	"recipeapp/database"
+	"recipeapp/models"
+	"recipeapp/nutrition"
+	"recipeapp/pricing"
+	"recipeapp/shoppinglist"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Handlers serve the API from the stores and sources they are constructed with, they keep no other state
+type Handlers struct {
+	Plans         PlanStore
+	Recipes       RecipeSource
+	ShoppingLists ShoppingListBuilder
+	Nutrition     nutrition.Table // without a table every ingredient is reported as missing
+}
+
+// PlanStore stores the plans, meals and settings of the users, *database.Repository implements it
+type PlanStore interface {
+	CreateEntry(ctx context.Context, entry database.RecipesEntry) (uuid.UUID, error)
+	GetUserEntries(ctx context.Context, user uuid.UUID) ([]database.RecipesEntry, error)
+	GetEntryByUUID(ctx context.Context, id uuid.UUID) (database.RecipesEntry, error)
+	UpdateEntry(ctx context.Context, entry database.RecipesEntry) error
+
+	CacheMeals(ctx context.Context, meals []models.Meal) error
+	GetCachedMeals(ctx context.Context, ids []string) (map[string]models.Meal, error)
+	GetAllCachedMeals(ctx context.Context) ([]models.Meal, error)
+	SearchMeals(ctx context.Context, query database.SearchQuery) ([]models.Meal, int, error)
+	GetTagCounts(ctx context.Context, user uuid.UUID) ([]database.TagCount, error)
+	GetMealIDsByTag(ctx context.Context, user uuid.UUID, tag string) ([]string, error)
+
+	CreateUserRecipe(ctx context.Context, owner uuid.UUID, meal models.Meal) (models.Meal, error)
+	GetUserRecipes(ctx context.Context, owner uuid.UUID) ([]models.Meal, error)
+	GetUserRecipe(ctx context.Context, owner uuid.UUID, id string) (models.Meal, error)
+	UpdateUserRecipe(ctx context.Context, owner uuid.UUID, meal models.Meal) error
+	DeleteUserRecipe(ctx context.Context, owner uuid.UUID, id string) error
+
+	GetRatings(ctx context.Context, user uuid.UUID) ([]database.MealRating, error)
+	GetRating(ctx context.Context, user uuid.UUID, id string) (database.MealRating, error)
+	SaveRating(ctx context.Context, rating database.MealRating) error
+	DeleteRating(ctx context.Context, user uuid.UUID, id string) error
+
+	CreateTemplate(ctx context.Context, template database.PlanTemplate) (database.PlanTemplate, error)
+	GetTemplates(ctx context.Context, owner uuid.UUID) ([]database.PlanTemplate, error)
+	GetTemplate(ctx context.Context, owner uuid.UUID, id uuid.UUID) (database.PlanTemplate, error)
+	DeleteTemplate(ctx context.Context, owner uuid.UUID, id uuid.UUID) error
+
+	GetRecurringRules(ctx context.Context) ([]database.RecurringRule, error)
+	GetRecurringRule(ctx context.Context, user uuid.UUID) (database.RecurringRule, error)
+	SaveRecurringRule(ctx context.Context, rule database.RecurringRule) error
+	DeleteRecurringRule(ctx context.Context, user uuid.UUID) error
+	ClaimRecurringRule(ctx context.Context, user uuid.UUID, slot, run time.Time) (bool, error)
+	ReleaseRecurringRule(ctx context.Context, user uuid.UUID, run, lastRun time.Time) error
+
+	GetDietProfile(ctx context.Context, user uuid.UUID) (database.DietProfile, error)
+	SaveDietProfile(ctx context.Context, profile database.DietProfile) error
+	GetPantry(ctx context.Context, user uuid.UUID) ([]string, error)
+	SavePantry(ctx context.Context, user uuid.UUID, ingredients []string) error
+
+	GetPrices(ctx context.Context) ([]pricing.Price, error)
+	SavePrices(ctx context.Context, prices []pricing.Price) error
+	DeletePrices(ctx context.Context, ingredient string, unit string) error
+
+	GetAisleOrder(ctx context.Context, user uuid.UUID, store string) ([]string, error)
+	SaveAisleOrder(ctx context.Context, user uuid.UUID, store string, aisleOrder []string) error
+	GetIngredientSections(ctx context.Context, user uuid.UUID) (map[string]string, error)
+	SaveIngredientSection(ctx context.Context, user uuid.UUID, ingredient string, section string) error
+	DeleteIngredientSection(ctx context.Context, user uuid.UUID, ingredient string) error
+}
+
+// RecipeSource fetches meals from TheMealDB and recipe pages to import, client.Client implements it
+type RecipeSource interface {
+	NewRecipe(ctx context.Context) (*client.Response, error)
+	LookupMeal(ctx context.Context, id string) (*client.Response, error)
+	FilterByIngredient(ctx context.Context, ingredient string) (*client.Response, error)
+	SearchMeals(ctx context.Context, name string) (*client.Response, error)
+	FetchPage(ctx context.Context, pageURL string) ([]byte, error)
+}
+
+// ShoppingListBuilder turns the meals of a plan into its shopping list, shoppinglist.Builder implements it
+type ShoppingListBuilder interface {
+	Build(meals []models.Meal, servings map[string]float64, mapper *shoppinglist.SectionMapper,
+		aisleOrder []shoppinglist.Section) shoppinglist.List
+}
@@ -0,0 +1,747 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"recipeapp/database"
+	"recipeapp/metrics"
+	"recipeapp/models"
+	"recipeapp/pricing"
+	"recipeapp/serverError"
+	"recipeapp/shoppinglist"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// catalogue returns meals that pass the planner, with distinct categories, areas and ingredients
+func catalogue(n int) []models.Meal {
+	categories := []string{"Beef", "Chicken", "Pasta", "Seafood", "Vegetarian", "Lamb", "Pork"}
+	areas := []string{"British", "Italian", "Japanese", "Mexican", "Indian", "French", "Thai"}
+	meals := make([]models.Meal, 0, n)
+	for i := 0; i < n; i++ {
+		meal := models.Meal{
+			IdMeal:      fmt.Sprint(53000 + i),
+			StrMeal:     fmt.Sprintf("Meal %d", i),
+			StrCategory: categories[i%len(categories)],
+			StrArea:     areas[i/len(categories)%len(areas)],
+		}
+		meal.SetIngredients([]models.Ingredient{
+			{Name: fmt.Sprintf("ingredient %d", i), Measure: "200g"},
+			{Name: "onion", Measure: "1"},
+		})
+		meals = append(meals, meal)
+	}
+	return meals
+}
+
+func newTestHandlers() (*Handlers, *fakeStore, *fakeSource) {
+	store := newFakeStore()
+	source := &fakeSource{}
+	return &Handlers{Plans: store, Recipes: source, ShoppingLists: shoppinglist.Builder{}}, store, source
+}
+
+// serve sends a request with the cookies to a router with the single route
+func serve(method string, route string, handler gin.HandlerFunc, target string, body string,
+	cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(serverError.Middleware())
+	router.Handle(method, route, handler)
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func userCookie(user uuid.UUID) *http.Cookie {
+	return &http.Cookie{Name: "user_cookie", Value: user.String()}
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("response %s: %v", rec.Body, err)
+	}
+}
+
+type planBody struct {
+	Recipe []models.Meal `json:"recipe"`
+}
+
+func TestNewRecipesFromSeed(t *testing.T) {
+	ctx := context.Background()
+	h, store, source := newTestHandlers()
+	store.CacheMeals(ctx, catalogue(30))
+	user := uuid.New()
+
+	rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes?seed=7", "", userCookie(user))
+	if rec.Code != 200 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var plan planBody
+	decode(t, rec, &plan)
+	if len(plan.Recipe) != 7 {
+		t.Errorf("%d meals planned, want 7", len(plan.Recipe))
+	}
+	if source.calls != 0 {
+		t.Errorf("TheMealDB called %d times for a seeded plan", source.calls)
+	}
+
+	var planCookie *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "recipe_cookie" {
+			planCookie = cookie
+		}
+	}
+	if planCookie == nil {
+		t.Fatal("no plan cookie set")
+	}
+	entry, err := store.GetEntryByUUID(ctx, uuid.MustParse(planCookie.Value))
+	if err != nil || entry.UserUUID != user || entry.Seed == nil || *entry.Seed != 7 {
+		t.Errorf("stored plan %+v (%v)", entry, err)
+	}
+
+	rec = serve("GET", "/api/recipes", h.GetRecipes, "/api/recipes", "", userCookie(user), planCookie)
+	var current planBody
+	decode(t, rec, &current)
+	if rec.Code != 200 || len(current.Recipe) != 7 || current.Recipe[0].IdMeal != plan.Recipe[0].IdMeal {
+		t.Errorf("current plan %d %+v, want the generated one", rec.Code, current.Recipe)
+	}
+}
+
+func TestNewRecipesCountsCategoryRejections(t *testing.T) {
+	ctx := context.Background()
+	h, store, _ := newTestHandlers()
+	meals := catalogue(30)
+	meals[3].StrCategory = "Dessert"
+	store.CacheMeals(ctx, meals)
+	rejections := metrics.CategoryRejections.WithLabelValues("Dessert")
+	before := testutil.ToFloat64(rejections)
+
+	rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes?seed=7", "")
+	if rec.Code != 200 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if n := testutil.ToFloat64(rejections) - before; n != 1 {
+		t.Errorf("%v dessert rejections counted, want 1", n)
+	}
+}
+
+func TestNewRecipesBudgetWarnsAboutUnpricedIngredients(t *testing.T) {
+	ctx := context.Background()
+	h, store, _ := newTestHandlers()
+	meals := catalogue(30)
+	store.CacheMeals(ctx, meals)
+	store.SavePrices(ctx, []pricing.Price{{Ingredient: "onion", Unit: "count", Per: 1, Price: 0.5}})
+
+	var body struct {
+		Warnings []string         `json:"warnings"`
+		Cost     pricing.Estimate `json:"cost"`
+	}
+	rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes?seed=7&budget=100&leftovers=2:1", "")
+	decode(t, rec, &body)
+	if rec.Code != 200 || len(body.Warnings) != 1 || !strings.Contains(body.Warnings[0], "ingredient") {
+		t.Errorf("status %d, warnings %v, want one about the unpriced ingredients", rec.Code, body.Warnings)
+	}
+	// the meal eaten again as leftovers costs as much as the budget counts it
+	if first := body.Cost.Meals[0]; first.Portions != 2 || first.Cost != 1 {
+		t.Errorf("first meal costs %+v, want 2 portions for 1", first)
+	}
+
+	for _, meal := range meals {
+		store.SavePrices(ctx, []pricing.Price{{Ingredient: meal.Ingredients()[0].Name, Unit: "g", Per: 1000, Price: 5}})
+	}
+	body.Warnings = nil
+	rec = serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes?seed=7&budget=100", "")
+	decode(t, rec, &body)
+	if rec.Code != 200 || len(body.Warnings) != 0 {
+		t.Errorf("status %d, warnings %v with every ingredient priced", rec.Code, body.Warnings)
+	}
+}
+
+func TestRegeneratePlan(t *testing.T) {
+	tests := []struct {
+		name   string
+		cached int
+		query  string
+	}{
+		{"discovered at TheMealDB", 5, ""},
+		{"from the cache with remote discoveries", 30, ""},
+		{"overlap with remote discoveries", 30, "?mode=overlap"},
+		{"seeded", 30, "?seed=7"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			h, store, source := newTestHandlers()
+			meals := catalogue(80)
+			store.CacheMeals(ctx, meals[:test.cached])
+			source.meals = meals[40:]
+			user := uuid.New()
+
+			rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes"+test.query, "", userCookie(user))
+			if rec.Code != 200 {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+			var id string
+			for _, cookie := range rec.Result().Cookies() {
+				if cookie.Name == "recipe_cookie" {
+					id = cookie.Value
+				}
+			}
+			entry, err := store.GetEntryByUUID(ctx, uuid.MustParse(id))
+			if err != nil || entry.Seed == nil || len(entry.Candidates) != test.cached {
+				t.Fatalf("stored plan %+v (%v), want a seed and %d candidates", entry, err, test.cached)
+			}
+			if test.query != "?seed=7" && len(entry.Discoveries) == 0 {
+				t.Errorf("no discoveries stored, TheMealDB was called %d times", source.calls)
+			}
+
+			// the cache grew since and TheMealDB is down, the plan is still generated the same way
+			store.CacheMeals(ctx, meals[test.cached:40])
+			source.err = serverError.BadInternalApiCall
+			rec = serve("GET", "/api/debug/plans/:id/regenerate", h.RegeneratePlan, "/api/debug/plans/"+id+"/regenerate",
+				"", userCookie(user))
+			var body struct {
+				Stored           []string `json:"stored"`
+				Identical        bool     `json:"identical"`
+				CatalogueChanged bool     `json:"catalogue_changed"`
+			}
+			decode(t, rec, &body)
+			if rec.Code != 200 || !body.Identical || body.CatalogueChanged || len(body.Stored) != 7 {
+				t.Errorf("status %d: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRegeneratePlanWithoutSeed(t *testing.T) {
+	ctx := context.Background()
+	h, store, _ := newTestHandlers()
+	user := uuid.New()
+	id, _ := store.CreateEntry(ctx, database.RecipesEntry{UserUUID: user, Meals: catalogue(7)})
+	rec := serve("GET", "/api/debug/plans/:id/regenerate", h.RegeneratePlan, "/api/debug/plans/"+id.String()+"/regenerate",
+		"", userCookie(user))
+	if rec.Code != 409 {
+		t.Errorf("status %d for a plan from before seeds were stored, want 409", rec.Code)
+	}
+}
+
+func TestNewRecipesFromTheMealDB(t *testing.T) {
+	h, store, source := newTestHandlers()
+	source.meals = catalogue(30)
+
+	rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes", "")
+	if rec.Code != 200 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var plan planBody
+	decode(t, rec, &plan)
+	if len(plan.Recipe) != 7 || source.calls < 7 {
+		t.Errorf("%d meals planned from %d calls", len(plan.Recipe), source.calls)
+	}
+	if len(store.entries) != 1 || len(store.cached) < 7 {
+		t.Errorf("%d plans and %d meals stored", len(store.entries), len(store.cached))
+	}
+}
+
+func TestNewRecipesKeepDiscoveringWithFullCache(t *testing.T) {
+	ctx := context.Background()
+	h, store, source := newTestHandlers()
+	meals := catalogue(minLocalCandidates + 10)
+	store.CacheMeals(ctx, meals[:minLocalCandidates])
+	source.meals = meals[minLocalCandidates:]
+
+	for i := 1; i <= 3; i++ {
+		rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes", "")
+		if rec.Code != 200 {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		if want := i * remoteDiscoveries; source.calls != want {
+			t.Errorf("%d calls to TheMealDB after %d plans, want %d", source.calls, i, want)
+		}
+	}
+	if want := minLocalCandidates + 3*remoteDiscoveries; len(store.cached) != want {
+		t.Errorf("%d meals cached after 3 plans, want %d", len(store.cached), want)
+	}
+
+	// the cache is enough if TheMealDB is down
+	source.err = serverError.BadInternalApiCall
+	if rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes", ""); rec.Code != 200 {
+		t.Errorf("status %d with TheMealDB down: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestNewRecipesWithTags(t *testing.T) {
+	ctx := context.Background()
+	h, store, source := newTestHandlers()
+	meals := catalogue(30)
+	for i := range meals[:10] {
+		meals[i].StrTags = "Quick,Pasta"
+	}
+	store.CacheMeals(ctx, meals)
+	source.meals = catalogue(40)[30:] // random meals without tags
+
+	rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes?tags=quick", "")
+	if rec.Code != 200 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var plan planBody
+	decode(t, rec, &plan)
+	for _, meal := range plan.Recipe {
+		if meal.StrTags == "" {
+			t.Errorf("untagged meal %s planned", meal.IdMeal)
+		}
+	}
+
+	// a tag random meals rarely have fails right away instead of asking TheMealDB again and again
+	rec = serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes?tags=rare", "")
+	var body serverError.Envelope
+	decode(t, rec, &body)
+	if rec.Code != 422 || body.Error.Code != serverError.CodeUnprocessable {
+		t.Errorf("status %d with %+v for an unknown tag, want 422", rec.Code, body)
+	}
+	if source.calls != 0 {
+		t.Errorf("TheMealDB called %d times for tagged plans", source.calls)
+	}
+}
+
+func TestNewRecipesTheMealDBDown(t *testing.T) {
+	h, store, source := newTestHandlers()
+	source.err = serverError.BadInternalApiCall
+
+	rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes", "")
+	if rec.Code != 503 {
+		t.Errorf("status %d, want 503", rec.Code)
+	}
+	if len(store.entries) != 0 {
+		t.Errorf("%d plans stored", len(store.entries))
+	}
+}
+
+func TestOwnRecipeRatings(t *testing.T) {
+	h, _, _ := newTestHandlers()
+	user := uuid.New()
+
+	rec := serve("POST", "/api/myrecipes", h.CreateMyRecipe, "/api/myrecipes",
+		`{"title": "Grandmas Soup", "ingredients": [{"name": "leek", "measure": "2"}]}`, userCookie(user))
+	if rec.Code != 201 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		Recipe models.Meal `json:"recipe"`
+	}
+	decode(t, rec, &created)
+	if !database.IsUserRecipeID(created.Recipe.IdMeal) {
+		t.Errorf("own recipe got id %q", created.Recipe.IdMeal)
+	}
+
+	rec = serve("PUT", "/api/ratings/:id", h.PutRating, "/api/ratings/"+created.Recipe.IdMeal,
+		`{"favourite": true}`, userCookie(user))
+	if rec.Code != 200 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	rec = serve("GET", "/api/ratings", h.ListRatings, "/api/ratings", "", userCookie(user))
+	var ratings struct {
+		Ratings []ratingResponse `json:"ratings"`
+	}
+	decode(t, rec, &ratings)
+	if len(ratings.Ratings) != 1 || ratings.Ratings[0].StrMeal != "Grandmas Soup" || !ratings.Ratings[0].Favourite {
+		t.Errorf("ratings %+v", ratings.Ratings)
+	}
+
+	rec = serve("GET", "/api/ratings", h.ListRatings, "/api/ratings", "", userCookie(uuid.New()))
+	decode(t, rec, &ratings)
+	if len(ratings.Ratings) != 0 {
+		t.Errorf("ratings %+v of another user", ratings.Ratings)
+	}
+}
+
+func TestRunRecurringRules(t *testing.T) {
+	ctx := context.Background()
+	h, store, _ := newTestHandlers()
+	store.CacheMeals(ctx, catalogue(30))
+	user := uuid.New()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local) // a Monday
+	store.rules[user] = database.RecurringRule{UserUUID: user, Weekday: time.Sunday, Minute: 18 * 60,
+		UpdatedAt: now.AddDate(0, 0, -7)}
+
+	h.RunRecurringRules(ctx, now)
+	entries, _ := store.GetUserEntries(ctx, user)
+	if len(entries) != 1 || entries[0].Source != database.SourceScheduled || len(entries[0].Meals) != 7 {
+		t.Fatalf("plans %+v after the rule was due", entries)
+	}
+	if !store.rules[user].LastRun.Equal(now) {
+		t.Errorf("rule last run %v, want %v", store.rules[user].LastRun, now)
+	}
+	if !entries[0].CreatedAt.Equal(now) {
+		t.Errorf("plan created %v, want the time of the check %v", entries[0].CreatedAt, now)
+	}
+	if next := toRecurringResponse(store.rules[user], now).NextRun; !next.Equal(time.Date(2026, 10, 25, 18, 0, 0, 0, time.Local)) {
+		t.Errorf("next run %v, want the coming Sunday", next)
+	}
+
+	h.RunRecurringRules(ctx, now.Add(time.Hour))
+	if entries, _ := store.GetUserEntries(ctx, user); len(entries) != 1 {
+		t.Errorf("%d plans after running twice in a week", len(entries))
+	}
+}
+
+// staleRulesStore returns the rules as they were before another server ran them
+type staleRulesStore struct {
+	*fakeStore
+	rules []database.RecurringRule
+}
+
+func (s staleRulesStore) GetRecurringRules(ctx context.Context) ([]database.RecurringRule, error) {
+	return s.rules, nil
+}
+
+func TestRunRecurringRulesOnTwoServers(t *testing.T) {
+	ctx := context.Background()
+	h, store, _ := newTestHandlers()
+	store.CacheMeals(ctx, catalogue(30))
+	user := uuid.New()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	store.rules[user] = database.RecurringRule{UserUUID: user, Weekday: time.Sunday, Minute: 18 * 60,
+		UpdatedAt: now.AddDate(0, 0, -7)}
+	stale, _ := store.GetRecurringRules(ctx)
+	other := &Handlers{Plans: staleRulesStore{store, stale}, Recipes: h.Recipes, ShoppingLists: h.ShoppingLists}
+
+	h.RunRecurringRules(ctx, now)
+	other.RunRecurringRules(ctx, now.Add(time.Second))
+	if entries, _ := store.GetUserEntries(ctx, user); len(entries) != 1 {
+		t.Errorf("%d plans after two servers ran the rule", len(entries))
+	}
+}
+
+func TestRunRecurringRulesRetriesWhenTheMealDBIsDown(t *testing.T) {
+	ctx := context.Background()
+	h, store, source := newTestHandlers()
+	source.meals = catalogue(30)
+	source.err = serverError.BadInternalApiCall
+	user := uuid.New()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	store.rules[user] = database.RecurringRule{UserUUID: user, Weekday: time.Sunday, Minute: 18 * 60,
+		UpdatedAt: now.AddDate(0, 0, -7)}
+
+	h.RunRecurringRules(ctx, now)
+	if entries, _ := store.GetUserEntries(ctx, user); len(entries) != 0 || !store.rules[user].LastRun.IsZero() {
+		t.Fatalf("plans %+v, last run %v with TheMealDB down", entries, store.rules[user].LastRun)
+	}
+	source.err = nil
+	h.RunRecurringRules(ctx, now.Add(time.Minute))
+	if entries, _ := store.GetUserEntries(ctx, user); len(entries) != 1 {
+		t.Errorf("%d plans once TheMealDB is back", len(entries))
+	}
+}
+
+// brokenStore fails to load plans like a database that cannot be reached
+type brokenStore struct {
+	*fakeStore
+}
+
+func (brokenStore) GetEntryByUUID(ctx context.Context, id uuid.UUID) (database.RecipesEntry, error) {
+	return database.RecipesEntry{}, errors.New("database is locked")
+}
+
+// pricelessStore fails to load the price catalogue, the last step of answering a new plan
+type pricelessStore struct {
+	*fakeStore
+}
+
+func (pricelessStore) GetPrices(ctx context.Context) ([]pricing.Price, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestNewRecipesFailureStoresNoPlan(t *testing.T) {
+	ctx := context.Background()
+	h, store, _ := newTestHandlers()
+	store.CacheMeals(ctx, catalogue(30))
+	h.Plans = pricelessStore{store}
+
+	rec := serve("GET", "/api/newrecipes", h.NewRecipes, "/api/newrecipes?seed=7", "")
+	if rec.Code != 500 {
+		t.Errorf("status %d, want 500", rec.Code)
+	}
+	if len(store.entries) != 0 {
+		t.Errorf("%d plans stored for a failed response", len(store.entries))
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "recipe_cookie" {
+			t.Errorf("plan cookie %q set for a failed response", cookie.Value)
+		}
+	}
+}
+
+func TestGetRecipesWithoutPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookie  string
+		broken  bool
+		status  int
+		code    serverError.Code
+		cleared bool
+	}{
+		{"no cookie", "", false, 404, serverError.CodeNoPlan, false},
+		{"invalid cookie", "not-a-plan", false, 400, serverError.CodeInvalidCookie, true},
+		{"stale cookie", uuid.NewString(), false, 404, serverError.CodePlanNotFound, true},
+		{"database down", uuid.NewString(), true, 500, serverError.CodeInternal, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, store, _ := newTestHandlers()
+			if test.broken {
+				h.Plans = brokenStore{store}
+			}
+			var cookies []*http.Cookie
+			if test.cookie != "" {
+				cookies = append(cookies, &http.Cookie{Name: "recipe_cookie", Value: test.cookie})
+			}
+
+			rec := serve("GET", "/api/recipes", h.GetRecipes, "/api/recipes", "", cookies...)
+			var body serverError.Envelope
+			decode(t, rec, &body)
+			if rec.Code != test.status || body.Error.Code != test.code {
+				t.Errorf("status %d with %+v, want %d %s", rec.Code, body, test.status, test.code)
+			}
+			cleared := false
+			for _, cookie := range rec.Result().Cookies() {
+				cleared = cleared || cookie.Name == "recipe_cookie" && cookie.MaxAge < 0
+			}
+			if cleared != test.cleared {
+				t.Errorf("plan cookie cleared %v, want %v", cleared, test.cleared)
+			}
+		})
+	}
+}
+
+func TestIngredientSectionsPerUser(t *testing.T) {
+	h, _, _ := newTestHandlers()
+	user, other := uuid.New(), uuid.New()
+
+	rec := serve("PUT", "/api/sections/:ingredient", h.PutIngredientSection, "/api/sections/leek",
+		`{"section": "frozen"}`, userCookie(user))
+	if rec.Code != 200 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var sections struct {
+		Overrides map[string]string `json:"overrides"`
+	}
+	decode(t, serve("GET", "/api/sections", h.GetSections, "/api/sections", "", userCookie(user)), &sections)
+	if sections.Overrides["leek"] != "frozen" {
+		t.Errorf("overrides %v", sections.Overrides)
+	}
+	sections.Overrides = nil
+	decode(t, serve("GET", "/api/sections", h.GetSections, "/api/sections", "", userCookie(other)), &sections)
+	if len(sections.Overrides) != 0 {
+		t.Errorf("overrides %v of another user", sections.Overrides)
+	}
+}
+
+// cookBody is the response of CookWhatIHave
+type cookBody struct {
+	Have  []string `json:"have"`
+	Meals []struct {
+		Recipe       models.Meal         `json:"recipe"`
+		Used         []string            `json:"used"`
+		Missing      []models.Ingredient `json:"missing"`
+		ShoppingList []string            `json:"shopping_list"`
+	} `json:"meals"`
+}
+
+func (body cookBody) ids() []string {
+	ids := make([]string, 0, len(body.Meals))
+	for _, meal := range body.Meals {
+		ids = append(ids, meal.Recipe.IdMeal)
+	}
+	return ids
+}
+
+func TestCookWhatIHave(t *testing.T) {
+	h, store, source := newTestHandlers()
+	user := uuid.New()
+	mealWith := func(id string, ingredients ...string) models.Meal {
+		meal := models.Meal{IdMeal: id, StrMeal: "Meal " + id, StrCategory: "Chicken"}
+		list := make([]models.Ingredient, 0, len(ingredients))
+		for _, name := range ingredients {
+			list = append(list, models.Ingredient{Name: name, Measure: "1"})
+		}
+		meal.SetIngredients(list)
+		return meal
+	}
+	store.cached["100"] = mealWith("100", "Chicken", "Rice", "Yoghurt")
+	store.cached["101"] = mealWith("101", "Chicken", "Leek")
+	store.cached["102"] = mealWith("102", "Spaghetti", "Tomato")
+	store.ratings[user.String()+"101"] = database.MealRating{UserUUID: user, IdMeal: "101", Blocked: true}
+	own, _ := store.CreateUserRecipe(context.Background(), user, mealWith("", "chicken breast", "rice", "water"))
+	store.CreateUserRecipe(context.Background(), uuid.New(), mealWith("", "chicken", "rice"))
+	source.meals = []models.Meal{mealWith("200", "Rice", "Black Beans")}
+
+	rec := serve("POST", "/api/cookwhatihave", h.CookWhatIHave, "/api/cookwhatihave",
+		`{"ingredients": ["chicken", " Rice ", "Chicken", ""]}`, userCookie(user))
+	if rec.Code != 200 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var body cookBody
+	decode(t, rec, &body)
+	if strings.Join(body.Have, ", ") != "chicken, Rice" {
+		t.Errorf("have %q, want the cleaned ingredients", body.Have)
+	}
+	// the own recipe misses nothing, the cached meal misses one and the meal of TheMealDB uses only the rice
+	if got := strings.Join(body.ids(), ", "); got != own.IdMeal+", 100, 200" {
+		t.Errorf("suggested %s", got)
+	}
+	if len(body.Meals) == 3 {
+		if len(body.Meals[0].ShoppingList) != 0 {
+			t.Errorf("shopping list %q for a meal missing nothing", body.Meals[0].ShoppingList)
+		}
+		if list := body.Meals[1].ShoppingList; len(list) != 1 || !strings.Contains(list[0], "Yoghurt") {
+			t.Errorf("shopping list %q, want the yoghurt", list)
+		}
+	}
+
+	source.err = errors.New("TheMealDB is down")
+	body = cookBody{}
+	decode(t, serve("POST", "/api/cookwhatihave", h.CookWhatIHave, "/api/cookwhatihave",
+		`{"ingredients": ["rice"], "limit": 1}`, userCookie(user)), &body)
+	if got := strings.Join(body.ids(), ", "); got != own.IdMeal {
+		t.Errorf("suggested %s with TheMealDB down and a limit of 1, want the own recipe", got)
+	}
+}
+
+func TestPantry(t *testing.T) {
+	h, store, _ := newTestHandlers()
+	user := uuid.New()
+	meal := models.Meal{IdMeal: "100", StrMeal: "Leek Soup", StrCategory: "Vegetarian", StrIngredient1: "Leeks",
+		StrIngredient2: "Potato"}
+	store.cached[meal.IdMeal] = meal
+
+	rec := serve("PUT", "/api/pantry", h.PutPantry, "/api/pantry", `{"ingredients": [" leek ", "Leek", "", "potato"]}`,
+		userCookie(user))
+	if rec.Code != 200 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var pantry struct {
+		Ingredients []string `json:"ingredients"`
+	}
+	decode(t, serve("GET", "/api/pantry", h.GetPantry, "/api/pantry", "", userCookie(user)), &pantry)
+	if strings.Join(pantry.Ingredients, ", ") != "leek, potato" {
+		t.Errorf("pantry %q, want the cleaned ingredients", pantry.Ingredients)
+	}
+
+	var body cookBody
+	decode(t, serve("POST", "/api/cookwhatihave", h.CookWhatIHave, "/api/cookwhatihave", `{}`, userCookie(user)), &body)
+	if len(body.Meals) != 1 || len(body.Meals[0].Used) != 2 || len(body.Meals[0].Missing) != 0 {
+		t.Errorf("suggestions %+v from the pantry", body.Meals)
+	}
+
+	rec = serve("POST", "/api/cookwhatihave", h.CookWhatIHave, "/api/cookwhatihave", `{}`, userCookie(uuid.New()))
+	if rec.Code != 400 {
+		t.Errorf("status %d without ingredients and pantry, want 400", rec.Code)
+	}
+}
+
+func TestExportPlanCalendar(t *testing.T) {
+	ctx := context.Background()
+	h, store, _ := newTestHandlers()
+	id, _ := store.CreateEntry(ctx, database.RecipesEntry{UserUUID: uuid.New(), Meals: catalogue(7)})
+	route := "/api/plan/:id/calendar.ics"
+
+	// calendar apps subscribe without the cookies of the page
+	rec := serve("GET", route, h.ExportPlanCalendar, "/api/plan/"+id.String()+"/calendar.ics", "")
+	if rec.Code != 200 || !strings.Contains(rec.Body.String(), "SUMMARY:Dinner: Meal 0") {
+		t.Errorf("status %d: %s", rec.Code, rec.Body)
+	}
+	for _, target := range []string{"/api/plan/" + uuid.NewString() + "/calendar.ics", "/api/plan/current/calendar.ics"} {
+		if rec := serve("GET", route, h.ExportPlanCalendar, target, ""); rec.Code != 404 {
+			t.Errorf("%s: status %d, want 404", target, rec.Code)
+		}
+	}
+}
+
+func TestExportCalendarStart(t *testing.T) {
+	ctx := context.Background()
+	h, store, _ := newTestHandlers()
+	created := time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		weekStart time.Time
+		query     string
+		first     string
+	}{
+		{time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local), "", "DTSTART:20261019T180000"},
+		{time.Time{}, "", "DTSTART:20261017T180000"},
+		{time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local), "?start=2026-11-02&time=18:30", "DTSTART:20261102T183000"},
+	}
+	for _, test := range tests {
+		id, _ := store.CreateEntry(ctx, database.RecipesEntry{UserUUID: uuid.New(), Meals: catalogue(7),
+			WeekStart: test.weekStart, CreatedAt: created})
+		target := "/api/plan/" + id.String() + "/calendar.ics" + test.query
+		rec := serve("GET", "/api/plan/:id/calendar.ics", h.ExportPlanCalendar, target, "")
+		body := rec.Body.String()
+		if first := strings.Index(body, "DTSTART:"); rec.Code != 200 || first < 0 || !strings.HasPrefix(body[first:], test.first) {
+			t.Errorf("%s: status %d, want the first dinner at %s: %s", target, rec.Code, test.first, body)
+		}
+	}
+}
+
+// upload posts the content as multipart form field "file" to the import handler
+func upload(h *Handlers, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "recipe.json")
+	part.Write(content)
+	form.Close()
+	router := gin.New()
+	router.Use(serverError.Middleware())
+	router.POST("/api/import", h.ImportRecipe)
+	req := httptest.NewRequest("POST", "/api/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestImportUpload(t *testing.T) {
+	h, _, _ := newTestHandlers()
+	rec := upload(h, []byte(`{"@type": "Recipe", "name": "Stew", "recipeIngredient": ["1.5 kg beef", "2-3 cloves garlic"]}`))
+	if rec.Code != 201 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var imported struct {
+		Recipe models.Meal `json:"recipe"`
+	}
+	decode(t, rec, &imported)
+	if imported.Recipe.StrMeasure1 != "1 1/2 kg" || imported.Recipe.StrMeasure2 != "3 cloves" {
+		t.Errorf("measures %q, %q", imported.Recipe.StrMeasure1, imported.Recipe.StrMeasure2)
+	}
+
+	// oversized uploads are refused, not parsed in part
+	large := append([]byte(`{"@type": "Recipe", "name": "Stew", "description": "`), bytes.Repeat([]byte("x"), maxUploadSize)...)
+	large = append(large, `"}`...)
+	if rec := upload(h, large); rec.Code != 413 {
+		t.Errorf("status %d for an oversized upload: %s", rec.Code, rec.Body)
+	}
+	large = append(large, bytes.Repeat([]byte(" "), 2*maxFormOverhead)...)
+	if rec := upload(h, large); rec.Code != 413 {
+		t.Errorf("status %d for an upload over the form limit: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"recipeapp/cookie"
+	"recipeapp/database"
+	"recipeapp/planner"
+	"recipeapp/serverError"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type historyEntry struct {
+	ID        uuid.UUID `json:"id"`
+	Source    string    `json:"source"`
+	WeekStart string    `json:"week_start,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Meals     []string  `json:"meals"`
+	Current   bool      `json:"current"`
+	Calendar  string    `json:"calendar"` // iCalendar feed of the plan
+}
+
+// ListHistory returns the plans of the user, newest first, with the names of their meals
+func (h *Handlers) ListHistory(c *gin.Context) {
+	ctx := c.Request.Context()
+	entries, err := h.Plans.GetUserEntries(ctx, cookie.GetUserID(c))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	current := cookie.GetCookie(c)
+	history := make([]historyEntry, 0, len(entries))
+	for _, entry := range entries {
+		item := historyEntry{
+			ID:        entry.EntryUUID,
+			Source:    entry.Source,
+			CreatedAt: entry.CreatedAt,
+			Meals:     []string{},
+			Current:   entry.EntryUUID.String() == current,
+			Calendar:  calendarURL(entry.EntryUUID),
+		}
+		if !entry.WeekStart.IsZero() {
+			item.WeekStart = entry.WeekStart.Format(dateLayout)
+		}
+		for _, meal := range entry.Meals {
+			item.Meals = append(item.Meals, meal.StrMeal)
+		}
+		history = append(history, item)
+	}
+	c.JSON(200, gin.H{
+		"history": history,
+	})
+}
+
+// GetHistoryPlan returns a plan of the user like /api/recipes does for the current one
+func (h *Handlers) GetHistoryPlan(c *gin.Context) {
+	entry, ok := h.loadUserEntry(c)
+	if !ok {
+		return
+	}
+	h.respondHistoryPlan(c, entry)
+}
+
+// SelectHistoryPlan makes a plan of the user the current plan
+func (h *Handlers) SelectHistoryPlan(c *gin.Context) {
+	entry, ok := h.loadUserEntry(c)
+	if !ok {
+		return
+	}
+	cookie.SetCookie(c, entry.EntryUUID.String())
+	h.respondHistoryPlan(c, entry)
+}
+
+// loadUserEntry loads the plan of the :id parameter and answers 404 if the user does not own it
+func (h *Handlers) loadUserEntry(c *gin.Context) (database.RecipesEntry, bool) {
+	ctx := c.Request.Context()
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(serverError.New(serverError.CodeNotFound, "Plan not found"))
+		return database.RecipesEntry{}, false
+	}
+	entry, err := h.Plans.GetEntryByUUID(ctx, id)
+	if errors.Is(err, database.ErrNotFound) || err == nil && entry.UserUUID != cookie.GetUserID(c) {
+		c.Error(serverError.New(serverError.CodeNotFound, "Plan not found"))
+		return database.RecipesEntry{}, false
+	}
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return database.RecipesEntry{}, false
+	}
+	return entry, true
+}
+
+func (h *Handlers) respondHistoryPlan(c *gin.Context, entry database.RecipesEntry) {
+	response, err := h.planResponse(c.Request.Context(), cookie.GetUserID(c), c.Query("store"), entry.Meals, planner.Schedule(entry.Schedule))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	if !entry.WeekStart.IsZero() {
+		response["week_start"] = entry.WeekStart.Format(dateLayout)
+	}
+	c.JSON(200, response)
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"recipeapp/cookie"
+	"recipeapp/importer"
+	"recipeapp/serverError"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadSize limits the size of uploaded HTML or JSON-LD files
+const maxUploadSize = 5 << 20
+
+// maxFormOverhead is what a multipart form may add to the file, its boundaries and headers
+const maxFormOverhead = 64 << 10
+
+// errUploadTooLarge is returned for uploads over maxUploadSize, they are refused rather than parsed in part
+var errUploadTooLarge = errors.New("upload too large")
+
+type importRequest struct {
+	JSONLD json.RawMessage `json:"jsonld"`
+	URL    string          `json:"url"`
+}
+
+// ImportRecipe imports a schema.org Recipe and stores it as recipe of the user.
+// Accepts a JSON body with either pasted "jsonld" or a page "url",
+// or a multipart form with an HTML or JSON-LD "file".
+func (h *Handlers) ImportRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
+	var result importer.Result
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		result, err = importFromUpload(c)
+	} else {
+		result, err = h.importFromBody(c)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, errUploadTooLarge):
+			c.Error(serverError.New(serverError.CodeTooLarge, "Uploads are limited to 5 MB"))
+		case errors.Is(err, serverError.FailedPageFetch):
+			c.Error(serverError.Wrap(serverError.CodeBadGateway, "Failed to fetch recipe page", err))
+		case errors.Is(err, importer.ErrNoRecipe):
+			c.Error(serverError.New(serverError.CodeUnprocessable, "No schema.org Recipe found"))
+		default:
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid import: "+err.Error()))
+		}
+		return
+	}
+	if result.Meal.StrMeal == "" {
+		c.Error(serverError.New(serverError.CodeUnprocessable, "Recipe has no name"))
+		return
+	}
+
+	meal, err := h.Plans.CreateUserRecipe(ctx, cookie.GetUserID(c), result.Meal)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(201, gin.H{
+		"recipe":              meal,
+		"skipped_ingredients": result.SkippedIngredients,
+		"unparsed_measures":   result.UnparsedMeasures,
+	})
+}
+
+// importFromBody imports pasted JSON-LD or the JSON-LD of the page at the given URL
+func (h *Handlers) importFromBody(c *gin.Context) (importer.Result, error) {
+	ctx := c.Request.Context()
+	var req importRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return importer.Result{}, err
+	}
+	if len(req.JSONLD) > 0 {
+		// The JSON-LD may be pasted as object or as string containing the JSON
+		var pasted string
+		if err := json.Unmarshal(req.JSONLD, &pasted); err == nil {
+			return importer.FromJSONLD([]byte(pasted))
+		}
+		return importer.FromJSONLD(req.JSONLD)
+	}
+	if req.URL != "" {
+		page, err := h.Recipes.FetchPage(ctx, req.URL)
+		if err != nil {
+			return importer.Result{}, err
+		}
+		return importer.FromHTML(page)
+	}
+	return importer.Result{}, errors.New("either jsonld or url is required")
+}
+
+// importFromUpload imports an uploaded HTML page or JSON-LD document
+func importFromUpload(c *gin.Context) (importer.Result, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize+maxFormOverhead)
+	header, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return importer.Result{}, errUploadTooLarge
+	}
+	if err != nil {
+		return importer.Result{}, err
+	}
+	if header.Size > maxUploadSize {
+		return importer.Result{}, errUploadTooLarge
+	}
+	file, err := header.Open()
+	if err != nil {
+		return importer.Result{}, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxUploadSize+1))
+	if err != nil {
+		return importer.Result{}, err
+	}
+	if len(data) > maxUploadSize {
+		return importer.Result{}, errUploadTooLarge
+	}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return importer.FromJSONLD(data)
+	}
+	return importer.FromHTML(data)
+}
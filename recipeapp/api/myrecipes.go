@@ -0,0 +1,166 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"recipeapp/cookie"
+	"recipeapp/database"
+	"recipeapp/models"
+	"recipeapp/serverError"
+	"recipeapp/shoppinglist"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type recipeRequest struct {
+	Title        string              `json:"title"`
+	Category     string              `json:"category"`
+	Area         string              `json:"area"`
+	Ingredients  []models.Ingredient `json:"ingredients"`
+	Instructions string              `json:"instructions"`
+	ImageURL     string              `json:"image_url"`
+	Tags         []string            `json:"tags"`
+}
+
+// toMeal validates the request and maps it into a meal
+func (r recipeRequest) toMeal() (models.Meal, error) {
+	title := strings.TrimSpace(r.Title)
+	if title == "" {
+		return models.Meal{}, errors.New("title is required")
+	}
+	if len(r.Ingredients) == 0 {
+		return models.Meal{}, errors.New("at least one ingredient is required")
+	}
+	if len(r.Ingredients) > models.MaxIngredients {
+		return models.Meal{}, fmt.Errorf("at most %d ingredients are allowed", models.MaxIngredients)
+	}
+	ingredients := make([]models.Ingredient, 0, len(r.Ingredients))
+	for i, ingredient := range r.Ingredients {
+		name := strings.TrimSpace(ingredient.Name)
+		if name == "" {
+			return models.Meal{}, fmt.Errorf("ingredient %d has no name", i+1)
+		}
+		measure := strings.TrimSpace(ingredient.Measure)
+		if err := shoppinglist.ValidateMeasure(measure); err != nil {
+			return models.Meal{}, fmt.Errorf("ingredient %q: %v", name, err)
+		}
+		ingredients = append(ingredients, models.Ingredient{Name: name, Measure: measure})
+	}
+	image := strings.TrimSpace(r.ImageURL)
+	if image != "" {
+		u, err := url.Parse(image)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return models.Meal{}, errors.New("image_url must be an http or https URL")
+		}
+	}
+	var tags []string
+	for _, tag := range r.Tags {
+		if tag = strings.TrimSpace(strings.ReplaceAll(tag, ",", " ")); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	meal := models.Meal{
+		StrMeal:         title,
+		StrCategory:     strings.TrimSpace(r.Category),
+		StrArea:         strings.TrimSpace(r.Area),
+		StrInstructions: strings.TrimSpace(r.Instructions),
+		StrMealThumb:    image,
+		StrTags:         strings.Join(tags, ","),
+	}
+	meal.SetIngredients(ingredients)
+	return meal, nil
+}
+
+// ListMyRecipes returns all recipes of the user
+func (h *Handlers) ListMyRecipes(c *gin.Context) {
+	ctx := c.Request.Context()
+	recipes, err := h.Plans.GetUserRecipes(ctx, cookie.GetUserID(c))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, gin.H{
+		"recipes": recipes,
+	})
+}
+
+// GetMyRecipe returns a single recipe of the user
+func (h *Handlers) GetMyRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
+	recipe, err := h.Plans.GetUserRecipe(ctx, cookie.GetUserID(c), c.Param("id"))
+	if err != nil {
+		respondUserRecipeError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"recipe": recipe,
+	})
+}
+
+// CreateMyRecipe stores a new recipe for the user
+func (h *Handlers) CreateMyRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req recipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	meal, err := req.toMeal()
+	if err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, err.Error()))
+		return
+	}
+	recipe, err := h.Plans.CreateUserRecipe(ctx, cookie.GetUserID(c), meal)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(201, gin.H{
+		"recipe": recipe,
+	})
+}
+
+// UpdateMyRecipe replaces a recipe of the user
+func (h *Handlers) UpdateMyRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req recipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	meal, err := req.toMeal()
+	if err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, err.Error()))
+		return
+	}
+	meal.IdMeal = c.Param("id")
+	if err := h.Plans.UpdateUserRecipe(ctx, cookie.GetUserID(c), meal); err != nil {
+		respondUserRecipeError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"recipe": meal,
+	})
+}
+
+// DeleteMyRecipe deletes a recipe of the user
+func (h *Handlers) DeleteMyRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
+	if err := h.Plans.DeleteUserRecipe(ctx, cookie.GetUserID(c), c.Param("id")); err != nil {
+		respondUserRecipeError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// respondUserRecipeError answers 404 for recipes the user does not own and 500 otherwise
+func respondUserRecipeError(c *gin.Context, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		c.Error(serverError.New(serverError.CodeNotFound, "Recipe not found"))
+		return
+	}
+	c.Error(serverError.Internal(err))
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// GetNutrition estimates calories, protein, fat and carbohydrates of the users plan per meal and per week
+func (h *Handlers) GetNutrition(c *gin.Context) {
+	entry, ok := h.currentPlan(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, h.Nutrition.Plan(entry.Meals))
+}
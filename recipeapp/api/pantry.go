@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"recipeapp/cookie"
+	"recipeapp/logging"
+	"recipeapp/models"
+	"recipeapp/planner"
+	"recipeapp/serverError"
+	"recipeapp/shoppinglist"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxFilterCalls limits the TheMealDB filter calls of a single request
+	maxFilterCalls = 10
+	// maxLookups limits how many meals found by the filter are looked up in full
+	maxLookups = 20
+	// defaultSuggestions is the number of meals proposed if no limit is given
+	defaultSuggestions = 10
+)
+
+type cookRequest struct {
+	Ingredients []string `json:"ingredients"`
+	UsePantry   bool     `json:"use_pantry"`
+	Limit       int      `json:"limit"`
+}
+
+type cookSuggestion struct {
+	planner.Match
+	ShoppingList []string `json:"shopping_list"`
+}
+
+// GetPantry returns the ingredients the user has at home
+func (h *Handlers) GetPantry(c *gin.Context) {
+	ctx := c.Request.Context()
+	ingredients, err := h.Plans.GetPantry(ctx, cookie.GetUserID(c))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	if ingredients == nil {
+		ingredients = []string{}
+	}
+	c.JSON(200, gin.H{
+		"ingredients": ingredients,
+	})
+}
+
+// PutPantry replaces the ingredients the user has at home
+func (h *Handlers) PutPantry(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		Ingredients []string `json:"ingredients"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	ingredients := cleanIngredients(req.Ingredients)
+	if err := h.Plans.SavePantry(ctx, cookie.GetUserID(c), ingredients); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, gin.H{
+		"ingredients": ingredients,
+	})
+}
+
+// CookWhatIHave proposes meals that use the most of the given ingredients and need the fewest
+// extra purchases. Without ingredients in the request, or with use_pantry, the pantry is used.
+func (h *Handlers) CookWhatIHave(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req cookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultSuggestions
+	}
+	userID := cookie.GetUserID(c)
+	have := cleanIngredients(req.Ingredients)
+	if req.UsePantry || len(have) == 0 {
+		pantry, err := h.Plans.GetPantry(ctx, userID)
+		if err != nil {
+			c.Error(serverError.Internal(err))
+			return
+		}
+		have = cleanIngredients(append(have, pantry...))
+	}
+	if len(have) == 0 {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "No ingredients given and the pantry is empty"))
+		return
+	}
+
+	ownRecipes, err := h.Plans.GetUserRecipes(ctx, userID)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	candidates, err := h.ingredientCandidates(ctx, have, ownRecipes)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	prefs, err := h.loadPreferences(ctx, userID)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	profile, err := h.loadDietProfile(ctx, userID)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+
+	var allowed []models.Meal
+	for _, meal := range candidates {
+		if prefs.Blocked[meal.IdMeal] || len(profile.Check(meal)) > 0 {
+			continue
+		}
+		allowed = append(allowed, meal)
+	}
+	matches := planner.RankByIngredients(have, allowed)
+	if len(matches) > req.Limit {
+		matches = matches[:req.Limit]
+	}
+
+	suggestions := make([]cookSuggestion, 0, len(matches))
+	mapper := shoppinglist.NewSectionMapper(nil)
+	for _, match := range matches {
+		missing := models.Meal{}
+		missing.SetIngredients(match.Missing)
+		shoppingList := h.ShoppingLists.Build([]models.Meal{missing}, nil, mapper, shoppinglist.DefaultAisleOrder).Lines
+		if shoppingList == nil {
+			shoppingList = []string{}
+		}
+		suggestions = append(suggestions, cookSuggestion{Match: match, ShoppingList: shoppingList})
+	}
+	c.JSON(200, gin.H{
+		"have":  have,
+		"meals": suggestions,
+	})
+}
+
+// ingredientCandidates collects meals using any of the ingredients from the cache, the users own recipes
+// and TheMealDB's filter-by-ingredient endpoint. TheMealDB failing only narrows the candidates.
+func (h *Handlers) ingredientCandidates(ctx context.Context, have []string, ownRecipes []models.Meal) ([]models.Meal, error) {
+	cached, err := h.Plans.GetAllCachedMeals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make([]string, 0, len(have))
+	for _, ingredient := range have {
+		normalized = append(normalized, shoppinglist.NormalizeIngredient(ingredient))
+	}
+
+	candidates := make(map[string]models.Meal)
+	for _, meal := range append(cached, ownRecipes...) {
+		for _, ingredient := range meal.Ingredients() {
+			if planner.IngredientMatches(normalized, shoppinglist.NormalizeIngredient(ingredient.Name)) {
+				candidates[meal.IdMeal] = meal
+				break
+			}
+		}
+	}
+
+	// Meals found by more of the ingredients are looked up first
+	hits := make(map[string]int)
+	for i, ingredient := range have {
+		if i == maxFilterCalls {
+			break
+		}
+		resp, err := h.Recipes.FilterByIngredient(ctx, ingredient)
+		if err != nil {
+			logging.FromContext(ctx).Warn("filter by ingredient failed", "ingredient", ingredient, "error", err)
+			continue
+		}
+		for _, meal := range resp.Meals {
+			if _, ok := candidates[meal.IdMeal]; !ok {
+				hits[meal.IdMeal]++
+			}
+		}
+	}
+	ids := make([]string, 0, len(hits))
+	for id := range hits {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if hits[ids[i]] != hits[ids[j]] {
+			return hits[ids[i]] > hits[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > maxLookups {
+		ids = ids[:maxLookups]
+	}
+	fetched, err := h.resolveMeals(ctx, nil, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	meals := make([]models.Meal, 0, len(candidates)+len(fetched))
+	for _, meal := range candidates {
+		meals = append(meals, meal)
+	}
+	return append(meals, fetched...), nil
+}
+
+// cleanIngredients trims the ingredient names and drops empty and duplicate ones
+func cleanIngredients(ingredients []string) []string {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, ingredient := range ingredients {
+		ingredient = strings.TrimSpace(ingredient)
+		key := shoppinglist.NormalizeIngredient(ingredient)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, ingredient)
+	}
+	return cleaned
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"recipeapp/cookie"
+	"recipeapp/database"
+	"recipeapp/logging"
+	"recipeapp/models"
+	"recipeapp/planner"
+	"recipeapp/serverError"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// planResponse builds the response for a plan with its days, its shopping list, grouped by the sections
+// of the given store of the user, and the estimated cost. Meals eaten again as leftovers are bought for every day.
+func (h *Handlers) planResponse(ctx context.Context, user uuid.UUID, store string, recipes []models.Meal,
+	schedule planner.Schedule) (gin.H, error) {
+	order, err := h.loadAisleOrder(ctx, user, store)
+	if err != nil {
+		return nil, err
+	}
+	mapper, err := h.loadSectionMapper(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	servings := schedule.Servings(recipes)
+	list := h.ShoppingLists.Build(recipes, servings, mapper, order)
+	catalogue, err := h.loadCatalogue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"recipe":                 recipes,
+		"days":                   schedule.Days(recipes),
+		"shopping_list":          list.Lines,
+		"shopping_list_sections": list.Sections,
+		"cost":                   catalogue.Estimate(recipes, list.Items, servings),
+	}, nil
+}
+
+// currentPlan loads the plan of the plan cookie. Requests without a plan cookie, with an invalid one or with
+// one of a plan that does not exist anymore, e.g. after the database was reset, are answered with distinct codes.
+// Stale cookies are cleared so the next request starts without a plan.
+func (h *Handlers) currentPlan(c *gin.Context) (database.RecipesEntry, bool) {
+	ctx := c.Request.Context()
+	value := cookie.GetCookie(c)
+	if value == "" {
+		c.Error(serverError.New(serverError.CodeNoPlan, "No plan found, generate recipes first"))
+		return database.RecipesEntry{}, false
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		cookie.ClearCookie(c)
+		c.Error(serverError.New(serverError.CodeInvalidCookie, "Invalid plan cookie, generate recipes again"))
+		return database.RecipesEntry{}, false
+	}
+	entry, err := h.Plans.GetEntryByUUID(ctx, id)
+	if errors.Is(err, database.ErrNotFound) {
+		cookie.ClearCookie(c)
+		c.Error(serverError.New(serverError.CodePlanNotFound, "The plan does not exist anymore, generate recipes again"))
+		return database.RecipesEntry{}, false
+	}
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return database.RecipesEntry{}, false
+	}
+	return entry, true
+}
+
+// randomMeal fetches a single random meal from TheMealDB
+func (h *Handlers) randomMeal(ctx context.Context) (models.Meal, error) {
+	resp, err := h.Recipes.NewRecipe(ctx)
+	if err != nil {
+		return models.Meal{}, err
+	}
+	if len(resp.Meals) == 0 {
+		return models.Meal{}, serverError.BadInternalApiCall
+	}
+	return resp.Meals[0], nil
+}
+
+// loadPreferences collects the known good and blocked meals of a user
+func (h *Handlers) loadPreferences(ctx context.Context, user uuid.UUID) (planner.Preferences, error) {
+	prefs := planner.Preferences{
+		Blocked:        make(map[string]bool),
+		KnownGoodRatio: planner.DefaultKnownGoodRatio,
+	}
+	ratings, err := h.Plans.GetRatings(ctx, user)
+	if err != nil {
+		return prefs, err
+	}
+	ownRecipes, err := h.Plans.GetUserRecipes(ctx, user)
+	if err != nil {
+		return prefs, err
+	}
+
+	weights := make(map[string]float64)
+	for _, meal := range ownRecipes {
+		weights[meal.IdMeal] = planner.RatingWeight(false, 0, true)
+	}
+	for _, rating := range ratings {
+		if rating.Blocked {
+			prefs.Blocked[rating.IdMeal] = true
+			continue
+		}
+		_, own := weights[rating.IdMeal]
+		if weight := planner.RatingWeight(rating.Favourite, rating.Rating, own); weight > 0 {
+			weights[rating.IdMeal] = weight
+		}
+	}
+
+	ids := make([]string, 0, len(weights))
+	for id := range weights {
+		ids = append(ids, id)
+	}
+	meals, err := h.resolveMeals(ctx, ownRecipes, ids)
+	if err != nil {
+		return prefs, err
+	}
+	for _, meal := range meals {
+		prefs.KnownGood = append(prefs.KnownGood, planner.WeightedMeal{Meal: meal, Weight: weights[meal.IdMeal]})
+	}
+	return prefs, nil
+}
+
+// resolveMeals looks up the meals of the given ids in the own recipes, the cache and at last TheMealDB.
+// Meals that cannot be found anywhere are left out.
+func (h *Handlers) resolveMeals(ctx context.Context, ownRecipes []models.Meal, ids []string) ([]models.Meal, error) {
+	var meals []models.Meal
+	var missing []string
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+		if !database.IsUserRecipeID(id) {
+			missing = append(missing, id)
+		}
+	}
+	for _, meal := range ownRecipes {
+		if wanted[meal.IdMeal] {
+			meals = append(meals, meal)
+		}
+	}
+	if len(missing) == 0 {
+		return meals, nil
+	}
+
+	cached, err := h.Plans.GetCachedMeals(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	var fetched []models.Meal
+	for _, id := range missing {
+		if meal, ok := cached[id]; ok {
+			meals = append(meals, meal)
+			continue
+		}
+		resp, err := h.Recipes.LookupMeal(ctx, id)
+		if err != nil || len(resp.Meals) == 0 {
+			logging.FromContext(ctx).Warn("could not look up meal", "meal", id, "error", err)
+			continue
+		}
+		meals = append(meals, resp.Meals[0])
+		fetched = append(fetched, resp.Meals[0])
+	}
+	if err := h.Plans.CacheMeals(ctx, fetched); err != nil {
+		logging.FromContext(ctx).Warn("caching meals failed", "error", err)
+	}
+	return meals, nil
+}
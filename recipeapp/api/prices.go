@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"io"
+	"recipeapp/pricing"
+	"recipeapp/serverError"
+	"recipeapp/shoppinglist"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type priceRequest struct {
+	Per   float64 `json:"per"`
+	Unit  string  `json:"unit"`
+	Price float64 `json:"price"`
+}
+
+// ListPrices returns the whole price catalogue
+func (h *Handlers) ListPrices(c *gin.Context) {
+	ctx := c.Request.Context()
+	prices, err := h.Plans.GetPrices(ctx)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, gin.H{
+		"prices": prices,
+	})
+}
+
+// PutPrice sets the price of an ingredient for one unit, e.g. {"per": 1, "unit": "kg", "price": 2.49}
+func (h *Handlers) PutPrice(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req priceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	price, err := pricing.NewPrice(c.Param("ingredient"), req.Per, req.Unit, req.Price)
+	if err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, err.Error()))
+		return
+	}
+	if err := h.Plans.SavePrices(ctx, []pricing.Price{price}); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, price)
+}
+
+// DeletePrice removes the prices of an ingredient, only the one of ?unit= if given
+func (h *Handlers) DeletePrice(c *gin.Context) {
+	ctx := c.Request.Context()
+	unit := c.Query("unit")
+	if unit != "" {
+		_, unit = shoppinglist.StandardizeUnit(1, unit)
+	}
+	ingredient := shoppinglist.NormalizeIngredient(c.Param("ingredient"))
+	if err := h.Plans.DeletePrices(ctx, ingredient, unit); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.Status(204)
+}
+
+// ImportPrices adds or replaces prices from a CSV with the columns ingredient, per, unit, price.
+// The CSV is sent as request body or as multipart form field "file".
+func (h *Handlers) ImportPrices(c *gin.Context) {
+	ctx := c.Request.Context()
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Missing file"))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid file"))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	prices, err := pricing.ParseCSV(io.LimitReader(body, maxUploadSize))
+	if err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid CSV: "+err.Error()))
+		return
+	}
+	if err := h.Plans.SavePrices(ctx, prices); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, gin.H{
+		"imported": len(prices),
+	})
+}
+
+// loadCatalogue reads the price catalogue from the database
+func (h *Handlers) loadCatalogue(ctx context.Context) (pricing.Catalogue, error) {
+	prices, err := h.Plans.GetPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pricing.NewCatalogue(prices), nil
+}
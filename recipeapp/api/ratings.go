@@ -0,0 +1,111 @@
+package api
+
+import (
+	"recipeapp/cookie"
+	"recipeapp/database"
+	"recipeapp/serverError"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ratingRequest struct {
+	Favourite *bool `json:"favourite"`
+	Rating    *int  `json:"rating"`
+	Blocked   *bool `json:"blocked"`
+}
+
+type ratingResponse struct {
+	IdMeal    string `json:"idMeal"`
+	StrMeal   string `json:"strMeal,omitempty"`
+	Favourite bool   `json:"favourite"`
+	Rating    int    `json:"rating"`
+	Blocked   bool   `json:"blocked"`
+}
+
+// ListRatings returns all favourites, ratings and blocked meals of the user
+func (h *Handlers) ListRatings(c *gin.Context) {
+	ctx := c.Request.Context()
+	ratings, err := h.Plans.GetRatings(ctx, cookie.GetUserID(c))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	ids := make([]string, 0, len(ratings))
+	for _, rating := range ratings {
+		ids = append(ids, rating.IdMeal)
+	}
+	// Names are added where the meal is cached, no API calls for a listing
+	cached, err := h.Plans.GetCachedMeals(ctx, ids)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	response := make([]ratingResponse, 0, len(ratings))
+	for _, rating := range ratings {
+		name := cached[rating.IdMeal].StrMeal
+		if database.IsUserRecipeID(rating.IdMeal) {
+			if meal, err := h.Plans.GetUserRecipe(ctx, rating.UserUUID, rating.IdMeal); err == nil {
+				name = meal.StrMeal
+			}
+		}
+		response = append(response, ratingResponse{
+			IdMeal:    rating.IdMeal,
+			StrMeal:   name,
+			Favourite: rating.Favourite,
+			Rating:    rating.Rating,
+			Blocked:   rating.Blocked,
+		})
+	}
+	c.JSON(200, gin.H{
+		"ratings": response,
+	})
+}
+
+// PutRating stars, rates or blocks a meal. Fields left out of the request keep their value,
+// a rating of 0 removes the rating.
+func (h *Handlers) PutRating(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req ratingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	if req.Rating != nil && (*req.Rating < 0 || *req.Rating > 5) {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "rating must be between 1 and 5, or 0 to remove it"))
+		return
+	}
+	rating, err := h.Plans.GetRating(ctx, cookie.GetUserID(c), c.Param("id"))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	if req.Favourite != nil {
+		rating.Favourite = *req.Favourite
+	}
+	if req.Rating != nil {
+		rating.Rating = *req.Rating
+	}
+	if req.Blocked != nil {
+		rating.Blocked = *req.Blocked
+	}
+	if err := h.Plans.SaveRating(ctx, rating); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, ratingResponse{
+		IdMeal:    rating.IdMeal,
+		Favourite: rating.Favourite,
+		Rating:    rating.Rating,
+		Blocked:   rating.Blocked,
+	})
+}
+
+// DeleteRating forgets everything the user said about a meal
+func (h *Handlers) DeleteRating(c *gin.Context) {
+	ctx := c.Request.Context()
+	if err := h.Plans.DeleteRating(ctx, cookie.GetUserID(c), c.Param("id")); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.Status(204)
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"recipeapp/cookie"
+	"recipeapp/database"
+	"recipeapp/logging"
+	"recipeapp/planner"
+	"recipeapp/scheduler"
+	"recipeapp/serverError"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type recurringRequest struct {
+	Weekday    string            `json:"weekday"`     // e.g. "saturday"
+	Time       string            `json:"time"`        // HH:MM, defaults to 00:00
+	Options    map[string]string `json:"options"`     // options of /api/newrecipes, e.g. {"mode": "overlap"}
+	TemplateID *uuid.UUID        `json:"template_id"` // apply this template instead of generating
+}
+
+type recurringResponse struct {
+	Weekday    string            `json:"weekday"`
+	Time       string            `json:"time"`
+	Options    map[string]string `json:"options"`
+	TemplateID *uuid.UUID        `json:"template_id,omitempty"`
+	LastRun    *time.Time        `json:"last_run,omitempty"`
+	NextRun    time.Time         `json:"next_run"`
+}
+
+// toRecurringResponse describes a rule, its next run is the first slot after now
+func toRecurringResponse(rule database.RecurringRule, now time.Time) recurringResponse {
+	response := recurringResponse{
+		Weekday:    strings.ToLower(rule.Weekday.String()),
+		Time:       fmt.Sprintf("%02d:%02d", rule.Minute/60, rule.Minute%60),
+		Options:    map[string]string{},
+		TemplateID: rule.TemplateUUID,
+		NextRun:    scheduler.LastSlot(rule.Weekday, rule.Minute, now).AddDate(0, 0, 7),
+	}
+	options, _ := url.ParseQuery(rule.Options)
+	for key := range options {
+		response.Options[key] = options.Get(key)
+	}
+	if !rule.LastRun.IsZero() {
+		response.LastRun = &rule.LastRun
+	}
+	return response
+}
+
+// GetRecurring returns the recurring plan rule of the user
+func (h *Handlers) GetRecurring(c *gin.Context) {
+	ctx := c.Request.Context()
+	rule, err := h.Plans.GetRecurringRule(ctx, cookie.GetUserID(c))
+	if err != nil {
+		respondRecurringError(c, err)
+		return
+	}
+	c.JSON(200, toRecurringResponse(rule, time.Now()))
+}
+
+// PutRecurring sets the recurring plan rule of the user,
+// e.g. {"weekday": "saturday", "time": "09:00", "options": {"mode": "overlap"}}
+func (h *Handlers) PutRecurring(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req recurringRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	weekday, ok := parseWeekday(req.Weekday)
+	if !ok {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid weekday: "+req.Weekday))
+		return
+	}
+	minute := 0
+	if req.Time != "" {
+		t, err := time.Parse("15:04", req.Time)
+		if err != nil {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid time, expected HH:MM"))
+			return
+		}
+		minute = t.Hour()*60 + t.Minute()
+	}
+	options := url.Values{}
+	for key, value := range req.Options {
+		options.Set(key, value)
+	}
+
+	userID := cookie.GetUserID(c)
+	if req.TemplateID != nil {
+		if _, err := h.Plans.GetTemplate(ctx, userID, *req.TemplateID); err != nil {
+			respondTemplateError(c, err)
+			return
+		}
+	} else if err := h.applyPlanOptions(ctx, &planner.Preferences{}, options); err != nil {
+		respondPlanError(c, err)
+		return
+	}
+	rule := database.RecurringRule{
+		UserUUID:     userID,
+		Weekday:      weekday,
+		Minute:       minute,
+		Options:      options.Encode(),
+		TemplateUUID: req.TemplateID,
+	}
+	if err := h.Plans.SaveRecurringRule(ctx, rule); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, toRecurringResponse(rule, time.Now()))
+}
+
+// DeleteRecurring stops the recurring plans of the user
+func (h *Handlers) DeleteRecurring(c *gin.Context) {
+	ctx := c.Request.Context()
+	if err := h.Plans.DeleteRecurringRule(ctx, cookie.GetUserID(c)); err != nil {
+		respondRecurringError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// RunRecurringRules generates the plans of all rules whose slot passed since they were last run or changed.
+// The plans are for the week after the slot and are stored in the history of the user.
+// A rule is claimed in the database before it is run, so of several servers only one runs it.
+func (h *Handlers) RunRecurringRules(ctx context.Context, now time.Time) {
+	rules, err := h.Plans.GetRecurringRules(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("loading recurring rules failed", "error", err)
+		return
+	}
+	for _, rule := range rules {
+		since := rule.UpdatedAt
+		if rule.LastRun.After(since) {
+			since = rule.LastRun
+		}
+		if !scheduler.Due(rule.Weekday, rule.Minute, since, now) {
+			continue
+		}
+		slot := scheduler.LastSlot(rule.Weekday, rule.Minute, now)
+		claimed, err := h.Plans.ClaimRecurringRule(ctx, rule.UserUUID, slot, now)
+		if err != nil {
+			logging.FromContext(ctx).Error("claiming recurring rule failed", "error", err)
+			continue
+		}
+		if !claimed {
+			continue // another server runs the rule for this slot
+		}
+		if err := h.runRecurringRule(ctx, rule, scheduler.NextWeek(slot), now); err != nil {
+			logging.FromContext(ctx).Error("recurring plan failed", "error", err)
+			if errors.Is(err, serverError.BadInternalApiCall) {
+				// TheMealDB may be back at the next check
+				if err := h.Plans.ReleaseRecurringRule(ctx, rule.UserUUID, now, rule.LastRun); err != nil {
+					logging.FromContext(ctx).Error("releasing recurring rule failed", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// runRecurringRule stores a new plan for the user of the rule, from its template or generated with its options.
+// Generated plans are created as of now, the time the scheduler checked the rule.
+func (h *Handlers) runRecurringRule(ctx context.Context, rule database.RecurringRule, weekStart, now time.Time) error {
+	if rule.TemplateUUID != nil {
+		template, err := h.Plans.GetTemplate(ctx, rule.UserUUID, *rule.TemplateUUID)
+		if err != nil {
+			return err
+		}
+		_, err = h.applyTemplate(ctx, template, weekStart, database.SourceScheduled)
+		return err
+	}
+	options, err := url.ParseQuery(rule.Options)
+	if err != nil {
+		return err
+	}
+	g, err := h.generatePlan(ctx, rule.UserUUID, options, now)
+	if err != nil {
+		return err
+	}
+	entry := g.entry(rule.UserUUID)
+	entry.WeekStart = weekStart
+	entry.Source = database.SourceScheduled
+	entry.CreatedAt = now
+	_, err = h.savePlan(ctx, entry, g.discovered...)
+	return err
+}
+
+// parseWeekday reads an English weekday, full or abbreviated to three letters
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 3 {
+		return 0, false
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if strings.HasPrefix(name, s) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// respondRecurringError answers 404 if the user has no rule and 500 otherwise
+func respondRecurringError(c *gin.Context, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		c.Error(serverError.New(serverError.CodeNotFound, "No recurring plan set"))
+		return
+	}
+	c.Error(serverError.Internal(err))
+}
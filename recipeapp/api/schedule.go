@@ -0,0 +1,102 @@
+package api
+
+import (
+	"recipeapp/cookie"
+	"recipeapp/database"
+	"recipeapp/models"
+	"recipeapp/planner"
+	"recipeapp/serverError"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type dayRequest struct {
+	Kind        planner.DayKind `json:"kind"`
+	LeftoversOf int             `json:"leftovers_of"`
+	IdMeal      string          `json:"idMeal"` // meal to cook, needed if nothing was cooked on the day before
+}
+
+// PutPlanDay changes a day of the users plan to cooking, eating the leftovers of an earlier day or eating out,
+// e.g. {"kind": "leftovers", "leftovers_of": 1}. The meal of a day that is no longer cooked is removed from the plan.
+func (h *Handlers) PutPlanDay(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req dayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	entry, ok := h.currentPlan(c)
+	if !ok {
+		return
+	}
+	schedule := planner.Schedule(entry.Schedule)
+	if len(schedule) == 0 {
+		schedule = planner.CookingSchedule(len(entry.Meals))
+	}
+	day, err := strconv.Atoi(c.Param("day"))
+	if err != nil || day < 1 || day > len(schedule) {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Days are numbered from 1 to "+strconv.Itoa(len(schedule))))
+		return
+	}
+
+	changed := slices.Clone(schedule)
+	changed[day-1] = planner.Day{Kind: req.Kind}
+	if req.Kind == planner.Leftovers {
+		changed[day-1].LeftoversOf = req.LeftoversOf
+	}
+	if err := changed.Validate(); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, err.Error()))
+		return
+	}
+
+	// take the meal out of the plan and put it back at its new position if the day is still cooked
+	meals := slices.Clone([]models.Meal(entry.Meals))
+	var cooked *models.Meal
+	if index := schedule.MealIndex(day); index >= 0 && index < len(meals) {
+		cooked = &entry.Meals[index]
+		meals = slices.Delete(meals, index, index+1)
+	}
+	if req.Kind == planner.Cook {
+		if req.IdMeal != "" {
+			if slices.ContainsFunc(meals, func(m models.Meal) bool { return m.IdMeal == req.IdMeal }) {
+				c.Error(serverError.New(serverError.CodeInvalidRequest, "The meal is already planned, eat its leftovers instead"))
+				return
+			}
+			ownRecipes, err := h.Plans.GetUserRecipes(ctx, cookie.GetUserID(c))
+			if err != nil {
+				c.Error(serverError.Internal(err))
+				return
+			}
+			resolved, err := h.resolveMeals(ctx, ownRecipes, []string{req.IdMeal})
+			if err != nil {
+				c.Error(serverError.Internal(err))
+				return
+			}
+			if len(resolved) == 0 {
+				c.Error(serverError.New(serverError.CodeNotFound, "Recipe not found"))
+				return
+			}
+			cooked = &resolved[0]
+		}
+		if cooked == nil {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "idMeal is required to cook on day "+strconv.Itoa(day)))
+			return
+		}
+		meals = slices.Insert(meals, changed.MealIndex(day), *cooked)
+	}
+
+	entry.Meals = meals
+	entry.Schedule = database.ScheduleJSON(changed)
+	if err := h.Plans.UpdateEntry(ctx, entry); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	response, err := h.planResponse(ctx, cookie.GetUserID(c), c.Query("store"), meals, changed)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, response)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"recipeapp/database"
+	"recipeapp/serverError"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Search finds cached meals by ?q= in title, ingredients, tags, category and area, filtered by ?category=, ?area=,
+// ?ingredient= and ?tag= and paged by ?page= and ?limit=. Without local results the name is searched at TheMealDB
+// and the found meals are cached.
+func (h *Handlers) Search(c *gin.Context) {
+	ctx := c.Request.Context()
+	page, limit, ok := parsePaging(c)
+	if !ok {
+		return
+	}
+	query := database.SearchQuery{
+		Text:       c.Query("q"),
+		Category:   c.Query("category"),
+		Area:       c.Query("area"),
+		Ingredient: c.Query("ingredient"),
+		Tag:        c.Query("tag"),
+		Limit:      limit,
+		Offset:     (page - 1) * limit,
+	}
+	if query.IsEmpty() {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Missing search text or filter"))
+		return
+	}
+
+	meals, total, err := h.Plans.SearchMeals(ctx, query)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	source := "cache"
+	if total == 0 && query.Text != "" {
+		source = "themealdb"
+		resp, err := h.Recipes.SearchMeals(ctx, query.Text)
+		if err != nil {
+			c.Error(serverError.Wrap(serverError.CodeUnavailable, "Service unavailable", err))
+			return
+		}
+		if len(resp.Meals) > 0 {
+			if err := h.Plans.CacheMeals(ctx, resp.Meals); err != nil {
+				c.Error(serverError.Internal(err))
+				return
+			}
+			// search the cache again so filters, ordering and paging apply to the new meals too
+			if meals, total, err = h.Plans.SearchMeals(ctx, query); err != nil {
+				c.Error(serverError.Internal(err))
+				return
+			}
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"results": meals,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+		"source":  source,
+	})
+}
+
+// parsePaging reads ?page= and ?limit= and answers 400 if they are invalid
+func parsePaging(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid page"))
+		return 0, 0, false
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid limit, allowed are 1 to "+strconv.Itoa(maxPageLimit)))
+		return 0, 0, false
+	}
+	return page, limit, true
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"recipeapp/cookie"
+	"recipeapp/serverError"
+	"recipeapp/shoppinglist"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type aisleOrderRequest struct {
+	AisleOrder []string `json:"aisle_order"`
+}
+
+type ingredientSectionRequest struct {
+	Section string `json:"section"`
+}
+
+// GetStore returns the aisle order the user set for a store
+func (h *Handlers) GetStore(c *gin.Context) {
+	ctx := c.Request.Context()
+	store := c.Param("store")
+	order, err := h.loadAisleOrder(ctx, cookie.GetUserID(c), store)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, gin.H{
+		"store":       store,
+		"aisle_order": order,
+	})
+}
+
+// PutStore saves the aisle order of a store for the user, sections left out are walked last
+func (h *Handlers) PutStore(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req aisleOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	order := make([]string, 0, len(req.AisleOrder))
+	for _, name := range req.AisleOrder {
+		section, ok := shoppinglist.ParseSection(name)
+		if !ok {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Unknown section: "+name))
+			return
+		}
+		order = append(order, string(section))
+	}
+	store := c.Param("store")
+	if err := h.Plans.SaveAisleOrder(ctx, cookie.GetUserID(c), store, order); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, gin.H{
+		"store":       store,
+		"aisle_order": shoppinglist.CompleteAisleOrder(toSections(order)),
+	})
+}
+
+// GetSections returns the known sections and the ingredient overrides of the user
+func (h *Handlers) GetSections(c *gin.Context) {
+	ctx := c.Request.Context()
+	overrides, err := h.Plans.GetIngredientSections(ctx, cookie.GetUserID(c))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, gin.H{
+		"sections":  shoppinglist.DefaultAisleOrder,
+		"overrides": overrides,
+	})
+}
+
+// PutIngredientSection moves an ingredient into another section on the shopping lists of the user
+func (h *Handlers) PutIngredientSection(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req ingredientSectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	section, ok := shoppinglist.ParseSection(req.Section)
+	if !ok {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "Unknown section: "+req.Section))
+		return
+	}
+	ingredient := shoppinglist.NormalizeIngredient(c.Param("ingredient"))
+	if err := h.Plans.SaveIngredientSection(ctx, cookie.GetUserID(c), ingredient, string(section)); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, gin.H{
+		"ingredient": ingredient,
+		"section":    section,
+	})
+}
+
+// DeleteIngredientSection resets an ingredient to its default section for the user
+func (h *Handlers) DeleteIngredientSection(c *gin.Context) {
+	ctx := c.Request.Context()
+	ingredient := shoppinglist.NormalizeIngredient(c.Param("ingredient"))
+	if err := h.Plans.DeleteIngredientSection(ctx, cookie.GetUserID(c), ingredient); err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.Status(204)
+}
+
+// loadSectionMapper creates a SectionMapper with the ingredient overrides of the user
+func (h *Handlers) loadSectionMapper(ctx context.Context, user uuid.UUID) (*shoppinglist.SectionMapper, error) {
+	overrides, err := h.Plans.GetIngredientSections(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return shoppinglist.NewSectionMapper(toSectionMap(overrides)), nil
+}
+
+// loadAisleOrder returns the complete aisle order the user set for a store, the default order if there is none
+func (h *Handlers) loadAisleOrder(ctx context.Context, user uuid.UUID, store string) ([]shoppinglist.Section, error) {
+	if store == "" {
+		return shoppinglist.DefaultAisleOrder, nil
+	}
+	order, err := h.Plans.GetAisleOrder(ctx, user, store)
+	if err != nil {
+		return nil, err
+	}
+	return shoppinglist.CompleteAisleOrder(toSections(order)), nil
+}
+
+func toSections(names []string) []shoppinglist.Section {
+	sections := make([]shoppinglist.Section, 0, len(names))
+	for _, name := range names {
+		if section, ok := shoppinglist.ParseSection(name); ok {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+func toSectionMap(overrides map[string]string) map[string]shoppinglist.Section {
+	sections := make(map[string]shoppinglist.Section, len(overrides))
+	for ingredient, name := range overrides {
+		if section, ok := shoppinglist.ParseSection(name); ok {
+			sections[ingredient] = section
+		}
+	}
+	return sections
+}
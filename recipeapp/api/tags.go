@@ -0,0 +1,61 @@
+package api
+
+import (
+	"recipeapp/cookie"
+	"recipeapp/models"
+	"recipeapp/serverError"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTags returns the tags of the cached meals and the users own recipes with the number of meals per tag
+func (h *Handlers) ListTags(c *gin.Context) {
+	ctx := c.Request.Context()
+	tags, err := h.Plans.GetTagCounts(ctx, cookie.GetUserID(c))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(200, gin.H{
+		"tags": tags,
+	})
+}
+
+// GetTagMeals returns the meals with a tag, paged by ?page= and ?limit=
+func (h *Handlers) GetTagMeals(c *gin.Context) {
+	ctx := c.Request.Context()
+	page, limit, ok := parsePaging(c)
+	if !ok {
+		return
+	}
+	userID := cookie.GetUserID(c)
+	ids, err := h.Plans.GetMealIDsByTag(ctx, userID, c.Param("tag"))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	total := len(ids)
+	start := min((page-1)*limit, total)
+	ids = ids[start:min(start+limit, total)]
+
+	ownRecipes, err := h.Plans.GetUserRecipes(ctx, userID)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	meals, err := h.resolveMeals(ctx, ownRecipes, ids)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	if meals == nil {
+		meals = []models.Meal{}
+	}
+	c.JSON(200, gin.H{
+		"tag":     models.NormalizeTag(c.Param("tag")),
+		"results": meals,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
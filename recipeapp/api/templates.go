@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"recipeapp/cookie"
+	"recipeapp/database"
+	"recipeapp/planner"
+	"recipeapp/scheduler"
+	"recipeapp/serverError"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const dateLayout = "2006-01-02"
+
+type templateRequest struct {
+	Name string `json:"name"`
+}
+
+type applyRequest struct {
+	WeekStart string `json:"week_start"` // YYYY-MM-DD, next Monday if empty
+}
+
+type templateResponse struct {
+	ID        uuid.UUID            `json:"id"`
+	Name      string               `json:"name"`
+	Days      []planner.PlannedDay `json:"days"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+func toTemplateResponse(template database.PlanTemplate) templateResponse {
+	return templateResponse{
+		ID:        template.TemplateUUID,
+		Name:      template.Name,
+		Days:      planner.Schedule(template.Schedule).Days(template.Meals),
+		CreatedAt: template.CreatedAt,
+	}
+}
+
+// ListTemplates returns the plan templates of the user
+func (h *Handlers) ListTemplates(c *gin.Context) {
+	ctx := c.Request.Context()
+	templates, err := h.Plans.GetTemplates(ctx, cookie.GetUserID(c))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	response := make([]templateResponse, 0, len(templates))
+	for _, template := range templates {
+		response = append(response, toTemplateResponse(template))
+	}
+	c.JSON(200, gin.H{
+		"templates": response,
+	})
+}
+
+// CreateTemplate saves the current plan of the user as template under a name
+func (h *Handlers) CreateTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req templateRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.Error(serverError.New(serverError.CodeInvalidRequest, "A name is required"))
+		return
+	}
+	entry, ok := h.currentPlan(c)
+	if !ok {
+		return
+	}
+	template, err := h.Plans.CreateTemplate(ctx, database.PlanTemplate{
+		OwnerUUID: cookie.GetUserID(c),
+		Name:      strings.TrimSpace(req.Name),
+		Meals:     entry.Meals,
+		Schedule:  entry.Schedule,
+	})
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	c.JSON(201, toTemplateResponse(template))
+}
+
+// DeleteTemplate deletes a plan template of the user
+func (h *Handlers) DeleteTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(serverError.New(serverError.CodeNotFound, "Template not found"))
+		return
+	}
+	if err := h.Plans.DeleteTemplate(ctx, cookie.GetUserID(c), id); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// ApplyTemplate creates a plan from a template for the week starting at week_start and makes it the current plan
+func (h *Handlers) ApplyTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req applyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid request body"))
+			return
+		}
+	}
+	weekStart := scheduler.NextWeek(time.Now())
+	if req.WeekStart != "" {
+		var err error
+		weekStart, err = time.ParseInLocation(dateLayout, req.WeekStart, time.Local)
+		if err != nil {
+			c.Error(serverError.New(serverError.CodeInvalidRequest, "Invalid week_start, expected YYYY-MM-DD"))
+			return
+		}
+	}
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(serverError.New(serverError.CodeNotFound, "Template not found"))
+		return
+	}
+	userID := cookie.GetUserID(c)
+	template, err := h.Plans.GetTemplate(ctx, userID, templateID)
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+	response, err := h.planResponse(ctx, userID, c.Query("store"), template.Meals, planner.Schedule(template.Schedule))
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	id, err := h.applyTemplate(ctx, template, weekStart, database.SourceTemplate)
+	if err != nil {
+		c.Error(serverError.Internal(err))
+		return
+	}
+	cookie.SetCookie(c, id.String())
+	response["week_start"] = weekStart.Format(dateLayout)
+	c.JSON(201, response)
+}
+
+// applyTemplate stores the meals of a template as plan of its owner for a week
+func (h *Handlers) applyTemplate(ctx context.Context, template database.PlanTemplate, weekStart time.Time, source string) (uuid.UUID, error) {
+	return h.Plans.CreateEntry(ctx, database.RecipesEntry{
+		UserUUID:  template.OwnerUUID,
+		Meals:     template.Meals,
+		Schedule:  template.Schedule,
+		WeekStart: weekStart,
+		Source:    source,
+	})
+}
+
+// respondTemplateError answers 404 for templates the user does not own and 500 otherwise
+func respondTemplateError(c *gin.Context, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		c.Error(serverError.New(serverError.CodeNotFound, "Template not found"))
+		return
+	}
+	c.Error(serverError.Internal(err))
+}
@@ -1,44 +1,108 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"net/url"
+	"recipeapp/logging"
+	"recipeapp/metrics"
 	"recipeapp/models"
 	"recipeapp/serverError"
+	"strings"
+	"time"
 )
 
-var url = "https://www.themealdb.com/api/json/v1/1/random.php"
+var baseURL = "https://www.themealdb.com/api/json/v1/1/"
 
 type Response struct {
 	Meals []models.Meal `json:"meals"`
 }
 
+// Client calls TheMealDB and downloads recipe pages, it keeps no state so the zero value is ready to use
+type Client struct{}
+
 // Function to fetch a single random recipe from the external API
-func NewRecipe() (*Response, error) {
+func (Client) NewRecipe(ctx context.Context) (*Response, error) {
+	return fetchMeals(ctx, "random.php", nil)
+}
+
+// LookupMeal fetches a single recipe by its id, the response has no meals if the id is unknown
+func (Client) LookupMeal(ctx context.Context, id string) (*Response, error) {
+	return fetchMeals(ctx, "lookup.php", url.Values{"i": {id}})
+}
+
+// FilterByIngredient fetches the meals that use an ingredient.
+// Only id, name and thumbnail of the meals are filled, the response has no meals if none matches.
+func (Client) FilterByIngredient(ctx context.Context, ingredient string) (*Response, error) {
+	name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(ingredient)), " ", "_")
+	return fetchMeals(ctx, "filter.php", url.Values{"i": {name}})
+}
+
+// SearchMeals fetches the meals whose name contains the given text, the response has no meals if none matches
+func (Client) SearchMeals(ctx context.Context, name string) (*Response, error) {
+	return fetchMeals(ctx, "search.php", url.Values{"s": {strings.TrimSpace(name)}})
+}
+
+// fetchMeals calls an endpoint of the external API that answers with a list of meals
+// and counts the call and its duration by endpoint
+func fetchMeals(ctx context.Context, endpoint string, query url.Values) (*Response, error) {
+	start := time.Now()
+	r, err := requestMeals(ctx, endpoint, query)
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	metrics.MealDBRequests.WithLabelValues(endpoint, result).Inc()
+	metrics.MealDBDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+	return r, err
+}
+
+// requestMeals does the call of fetchMeals.
+// The call is canceled with ctx and carries the id of the request it is made for.
+func requestMeals(ctx context.Context, endpoint string, query url.Values) (*Response, error) {
 	client := &http.Client{}
 
-	req, err := http.NewRequest("GET", url, nil)
+	target := baseURL + endpoint
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	logger := logging.FromContext(ctx).With("endpoint", endpoint)
+	req, err := newRequest(ctx, target)
 	if err != nil {
-		fmt.Printf("Error creating request: %v\n", err)
+		logger.Error("creating TheMealDB request failed", "error", err)
 		return nil, serverError.BadInternalApiCall
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("Error making request: %v\n", err)
+		logger.Error("calling TheMealDB failed", "error", err)
 		return nil, serverError.BadInternalApiCall
 	}
 	defer resp.Body.Close()
 
 	if resp.Status != "200 OK" {
-		fmt.Printf("Non-200 response: %v\n", resp.Status)
+		logger.Error("TheMealDB answered with an error", "status", resp.Status)
 		return nil, serverError.BadInternalApiCall
 	}
 
 	r := Response{}
 	err = json.NewDecoder(resp.Body).Decode(&r)
 	if err != nil {
+		logger.Error("decoding TheMealDB response failed", "error", err)
 		return nil, serverError.BadInternalApiCall
 	}
+	logger.Debug("called TheMealDB", "meals", len(r.Meals))
 	return &r, nil
 }
+
+// newRequest returns a GET request canceled with ctx that forwards the request id of ctx
+func newRequest(ctx context.Context, target string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+	return req, nil
+}
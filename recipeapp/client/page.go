@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"recipeapp/logging"
+	"recipeapp/serverError"
+	"syscall"
+	"time"
+)
+
+// maxPageSize limits how much of a recipe page is read, larger pages are not imported
+const maxPageSize = 5 << 20
+
+// maxRedirects limits how often a page may redirect
+const maxRedirects = 5
+
+// errBlockedAddress is returned for pages on addresses of the server's own networks
+var errBlockedAddress = errors.New("address is not public")
+
+// blockedPrefixes are networks that are not covered by the checks of netip.Addr but are no public internet
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT, also used for cloud metadata
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, may translate to private IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+}
+
+// pageClient fetches recipe pages. Users choose the URLs, so it only connects to public addresses:
+// the addresses are checked after DNS resolution, for every redirect, and no proxy is used.
+var pageClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: dialPublicOnly}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+	},
+	CheckRedirect: checkRedirect,
+}
+
+// FetchPage downloads a recipe page so its JSON-LD can be imported
+func (Client) FetchPage(ctx context.Context, pageURL string) ([]byte, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil || !fetchable(u) {
+		return nil, serverError.InvalidPageURL
+	}
+
+	logger := logging.FromContext(ctx).With("host", u.Host)
+	req, err := newRequest(ctx, u.String())
+	if err != nil {
+		logger.Warn("creating page request failed", "error", err)
+		return nil, serverError.FailedPageFetch
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/ld+json")
+	resp, err := pageClient.Do(req)
+	if err != nil {
+		logger.Warn("fetching page failed", "error", err)
+		if errors.Is(err, errBlockedAddress) {
+			return nil, serverError.InvalidPageURL
+		}
+		return nil, serverError.FailedPageFetch
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		logger.Warn("page answered with an error", "status", resp.Status)
+		return nil, serverError.FailedPageFetch
+	}
+	if resp.ContentLength > maxPageSize {
+		logger.Warn("page is too large", "bytes", resp.ContentLength)
+		return nil, serverError.FailedPageFetch
+	}
+
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize+1))
+	if err != nil {
+		return nil, serverError.FailedPageFetch
+	}
+	if len(page) > maxPageSize {
+		logger.Warn("page is too large", "bytes", len(page))
+		return nil, serverError.FailedPageFetch
+	}
+	return page, nil
+}
+
+// fetchable reports whether a URL is an http(s) URL whose host, if it is an IP address, is public
+func fetchable(u *url.URL) bool {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+		return publicAddr(addr)
+	}
+	return true
+}
+
+// checkRedirect follows redirects to fetchable URLs only, their addresses are checked again when dialing
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if !fetchable(req.URL) {
+		return fmt.Errorf("redirect to %s: %w", req.URL.Host, errBlockedAddress)
+	}
+	return nil
+}
+
+// dialPublicOnly refuses connections to addresses that are not public, it runs after DNS resolution
+func dialPublicOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !publicAddr(addr) {
+		return fmt.Errorf("%s: %w", host, errBlockedAddress)
+	}
+	return nil
+}
+
+// publicAddr reports whether an address is on the public internet, and not loopback,
+// private, link-local (like the cloud metadata address 169.254.169.254), unspecified or multicast
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"recipeapp/serverError"
+	"testing"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.100.100.200", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, test := range tests {
+		if public := publicAddr(netip.MustParseAddr(test.addr)); public != test.public {
+			t.Errorf("publicAddr(%s) = %v, want %v", test.addr, public, test.public)
+		}
+	}
+}
+
+func TestFetchPageRefusesInternalAddresses(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+	port := server.URL[len("http://127.0.0.1:"):]
+
+	for _, pageURL := range []string{
+		server.URL,                             // loopback address
+		"http://localhost:" + port + "/recipe", // resolves to loopback
+		"http://169.254.169.254/latest/meta-data/",
+		"file:///etc/passwd",
+	} {
+		if _, err := (Client{}).FetchPage(context.Background(), pageURL); !errors.Is(err, serverError.InvalidPageURL) {
+			t.Errorf("FetchPage(%s) = %v, want %v", pageURL, err, serverError.InvalidPageURL)
+		}
+	}
+	if requested {
+		t.Error("internal server was requested")
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	redirect := func(target string) *http.Request {
+		u, _ := url.Parse(target)
+		return &http.Request{URL: u}
+	}
+	if err := checkRedirect(redirect("https://example.com/recipe"), nil); err != nil {
+		t.Errorf("redirect to a public page refused: %v", err)
+	}
+	if err := checkRedirect(redirect("http://10.0.0.1/admin"), nil); !errors.Is(err, errBlockedAddress) {
+		t.Errorf("redirect to a private address: %v", err)
+	}
+	if err := checkRedirect(redirect("ftp://example.com/recipe"), nil); err == nil {
+		t.Error("redirect to ftp followed")
+	}
+	via := make([]*http.Request, maxRedirects)
+	if err := checkRedirect(redirect("https://example.com/recipe"), via); err == nil {
+		t.Errorf("%d redirects followed", maxRedirects+1)
+	}
+}
+
+func TestFetchPageSizeLimit(t *testing.T) {
+	size := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, size))
+	}))
+	defer server.Close()
+	// the test server is on loopback, so the address check is left out
+	defer func(client *http.Client) { pageClient = client }(pageClient)
+	pageClient = server.Client()
+	pageURL := "http://localhost:" + server.URL[len("http://127.0.0.1:"):]
+
+	size = 1000
+	if page, err := (Client{}).FetchPage(context.Background(), pageURL); err != nil || len(page) != size {
+		t.Errorf("small page: %d bytes, %v", len(page), err)
+	}
+	size = maxPageSize + 1
+	if _, err := (Client{}).FetchPage(context.Background(), pageURL); !errors.Is(err, serverError.FailedPageFetch) {
+		t.Errorf("oversized page: %v, want %v", err, serverError.FailedPageFetch)
+	}
+}
@@ -1,7 +1,6 @@
 package cookie
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,17 +13,17 @@ func SetCookie(c *gin.Context, token string) {
 	// host-only cookie (empty domain), not Secure for local dev, HttpOnly true
 	// always set the cookie (don't rely on presence in request)
 	c.SetCookie("recipe_cookie", token, maxAge, "/", "", false, true)
+}
 
-	// debug: print Set-Cookie header(s) written to the response
-	fmt.Printf("Set-Cookie header(s) written: %v\n", c.Writer.Header().Values("Set-Cookie"))
-
+// ClearCookie tells the browser to forget the plan cookie, e.g. when its plan does not exist anymore
+func ClearCookie(c *gin.Context) {
+	c.SetCookie("recipe_cookie", "", -1, "/", "", false, true)
 }
 
 func GetCookie(c *gin.Context) string {
 	cookie, err := c.Cookie("recipe_cookie")
 	if err != nil {
-		fmt.Println("Error retrieving cookie:", err)
-		return ""
+		return "" // no plan yet, callers answer that themselves
 	}
 	return cookie
 }
@@ -0,0 +1,24 @@
+package cookie
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const userCookie = "user_cookie"
+
+// GetUserID returns the id of the user from the user cookie.
+// A new id is created and set as cookie if the request has none.
+func GetUserID(c *gin.Context) uuid.UUID {
+	if value, err := c.Cookie(userCookie); err == nil {
+		if id, err := uuid.Parse(value); err == nil {
+			return id
+		}
+	}
+	id := uuid.New()
+	maxAge := int((365 * 24 * time.Hour).Seconds())
+	c.SetCookie(userCookie, id.String(), maxAge, "/", "", false, true)
+	return id
+}
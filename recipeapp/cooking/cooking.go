@@ -0,0 +1,46 @@
+package cooking
+
+import "recipeapp/models"
+
+// Meal is a meal prepared for cooking mode
+type Meal struct {
+	IdMeal      string              `json:"idMeal"`
+	StrMeal     string              `json:"strMeal"`
+	Thumbnail   string              `json:"strMealThumb"`
+	Ingredients []models.Ingredient `json:"ingredients"`
+	Steps       []Step              `json:"steps"`
+	Timers      []Timer             `json:"timers"` // all timers of the steps in order
+	TotalTime   int                 `json:"total_time_seconds"`
+}
+
+// FromMeal splits the instructions of a meal into steps and collects its ingredients and timers.
+// The total time is the sum of the upper bounds of all timers and only a rough estimate.
+func FromMeal(meal models.Meal) Meal {
+	cooking := Meal{
+		IdMeal:      meal.IdMeal,
+		StrMeal:     meal.StrMeal,
+		Thumbnail:   meal.StrMealThumb,
+		Ingredients: meal.Ingredients(),
+		Steps:       ParseSteps(meal.StrInstructions),
+		Timers:      []Timer{},
+	}
+	if cooking.Ingredients == nil {
+		cooking.Ingredients = []models.Ingredient{}
+	}
+	for _, step := range cooking.Steps {
+		for _, timer := range step.Timers {
+			cooking.Timers = append(cooking.Timers, timer)
+			cooking.TotalTime += timer.MaxSeconds
+		}
+	}
+	return cooking
+}
+
+// FromMeals prepares all meals of a plan for cooking mode
+func FromMeals(meals []models.Meal) []Meal {
+	cooking := make([]Meal, 0, len(meals))
+	for _, meal := range meals {
+		cooking = append(cooking, FromMeal(meal))
+	}
+	return cooking
+}
@@ -0,0 +1,184 @@
+package cooking
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Step is a single numbered instruction step
+type Step struct {
+	Number int     `json:"number"`
+	Text   string  `json:"text"`
+	Timers []Timer `json:"timers"`
+}
+
+// stepMarker matches the numbering some recipes put in front of a step, like "STEP 1", "1. " or "2) ".
+// Numbers need whitespace after them, "2-3 eggs" starts a step rather than numbering it.
+var stepMarker = regexp.MustCompile(`(?i)^(?:step\s*\d+\s*[:.)-]?\s*|\d+\s*[.):-]\s+|\d+\s+(?:-|–)\s+)`)
+
+// bareNumber matches lines that only number the following step, like "1" or "STEP 2"
+var bareNumber = regexp.MustCompile(`(?i)^(?:step\s*)?\d+\s*[:.)]?$`)
+
+// sentenceEnd splits long single paragraph instructions into sentences
+var sentenceEnd = regexp.MustCompile(`[.!?]\s+`)
+
+// ParseSteps splits the instructions of a meal into numbered steps and detects the timers in them.
+// Instructions are split at line breaks, instructions without any are split into sentences.
+func ParseSteps(instructions string) []Step {
+	instructions = strings.ReplaceAll(instructions, "\r\n", "\n")
+	instructions = strings.ReplaceAll(instructions, "\r", "\n")
+
+	var texts []string
+	for _, line := range strings.Split(instructions, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || bareNumber.MatchString(line) {
+			continue
+		}
+		line = strings.TrimSpace(stripStepMarker(line))
+		if line != "" {
+			texts = append(texts, line)
+		}
+	}
+	if len(texts) == 1 {
+		texts = splitSentences(texts[0])
+	}
+
+	steps := make([]Step, 0, len(texts))
+	for i, text := range texts {
+		steps = append(steps, Step{
+			Number: i + 1,
+			Text:   text,
+			Timers: FindTimers(text),
+		})
+	}
+	return steps
+}
+
+// stripStepMarker removes the numbering in front of a step, unless a number follows it like in "2. 3 eggs, beaten"
+func stripStepMarker(line string) string {
+	marker := stepMarker.FindString(line)
+	rest := line[len(marker):]
+	if marker == "" || (rest != "" && rest[0] >= '0' && rest[0] <= '9') {
+		return line
+	}
+	return rest
+}
+
+// splitSentences splits a paragraph after every sentence
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		sentences = append(sentences, strings.TrimSpace(text[start:loc[0]+1]))
+		start = loc[1]
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// Timer is a duration mentioned in a step, e.g. "simmer for 20 minutes".
+// For ranges like "10-15 minutes" Seconds is the lower and MaxSeconds the upper bound.
+type Timer struct {
+	Text       string `json:"text"`
+	Seconds    int    `json:"seconds"`
+	MaxSeconds int    `json:"max_seconds"`
+}
+
+const (
+	// number is a numeral, a number word or an article, "an hour" is one hour.
+	// Tens come before units, so "twenty-five" is read as a whole.
+	number   = `(\d+(?:[.,]\d+)?|half an?|` + tens + `(?:[-\s]` + units + `)?|` + teens + `|` + units + `|an?)`
+	tens     = `(?:twenty|thirty|forty|fifty|sixty|seventy|eighty|ninety)`
+	teens    = `(?:eleven|twelve|thirteen|fourteen|fifteen|sixteen|seventeen|eighteen|nineteen)`
+	units    = `(?:one|two|three|four|five|six|seven|eight|nine|ten)`
+	timeUnit = `(seconds?|secs?|minutes?|mins?|hours?|hrs?)`
+)
+
+// duration matches amounts of time like "20 minutes", "1-2 hours", "10 to 15 mins", "forty-five minutes" or "half an hour"
+var duration = regexp.MustCompile(`(?i)\b` + number + `(?:\s*(?:-|–|to)\s*` + number + `)?\s*` + timeUnit + `\b`)
+
+var numberWords = map[string]float64{
+	"a": 1, "an": 1, "half a": 0.5, "half an": 0.5,
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15, "sixteen": 16, "seventeen": 17,
+	"eighteen": 18, "nineteen": 19, "twenty": 20, "thirty": 30, "forty": 40, "fifty": 50, "sixty": 60,
+	"seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+// FindTimers returns the durations mentioned in a text. Intervals like "stir every 5 minutes" are no timers.
+func FindTimers(text string) []Timer {
+	timers := []Timer{}
+	for _, loc := range duration.FindAllStringSubmatchIndex(text, -1) {
+		if strings.HasSuffix(strings.ToLower(strings.TrimSpace(text[:loc[0]])), "every") {
+			continue
+		}
+		match := submatches(text, loc)
+		unit := unitSeconds(match[3])
+		from, ok := parseNumber(match[1])
+		if !ok || (isArticle(match[1]) && unit == 1) {
+			continue // "for a second" is a figure of speech
+		}
+		to := from
+		if match[2] != "" {
+			if to, ok = parseNumber(match[2]); !ok || to < from {
+				to = from
+			}
+		}
+		timers = append(timers, Timer{
+			Text:       match[0],
+			Seconds:    int(from * unit),
+			MaxSeconds: int(to * unit),
+		})
+	}
+	return timers
+}
+
+// submatches returns the matched texts for the indexes of a match, empty for groups that did not match
+func submatches(text string, loc []int) []string {
+	match := make([]string, len(loc)/2)
+	for i := range match {
+		if loc[2*i] >= 0 {
+			match[i] = text[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return match
+}
+
+// parseNumber reads a digit or number word amount, compound words like "twenty-five" add up their parts
+func parseNumber(s string) (float64, bool) {
+	s = strings.ToLower(s)
+	if n, ok := numberWords[s]; ok {
+		return n, true
+	}
+	if parts := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || unicode.IsSpace(r) }); len(parts) == 2 {
+		tens, ok := numberWords[parts[0]]
+		units, unitsOk := numberWords[parts[1]]
+		if ok && unitsOk {
+			return tens + units, true
+		}
+	}
+	n, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+	return n, err == nil
+}
+
+// isArticle reports whether a number is "a" or "an"
+func isArticle(s string) bool {
+	s = strings.ToLower(s)
+	return s == "a" || s == "an"
+}
+
+// unitSeconds returns the length of a time unit in seconds
+func unitSeconds(unit string) float64 {
+	switch unit = strings.ToLower(unit); {
+	case strings.HasPrefix(unit, "h"):
+		return 3600
+	case strings.HasPrefix(unit, "m"):
+		return 60
+	default:
+		return 1
+	}
+}
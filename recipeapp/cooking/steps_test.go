@@ -0,0 +1,77 @@
+package cooking
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindTimers(t *testing.T) {
+	tests := []struct {
+		text    string
+		seconds []int
+	}{
+		{"Simmer for 20 minutes.", []int{1200}},
+		{"Bake for 1-2 hours, then rest for 10 mins.", []int{3600, 600}},
+		{"Leave to stand for half an hour.", []int{1800}},
+		{"Boil for two minutes.", []int{120}},
+		{"Stir every 5 minutes.", nil},
+		{"Stir every fifteen minutes.", nil},
+		{"Simmer for fifteen minutes.", []int{900}},
+		{"Roast for twenty-five to thirty minutes.", []int{1500}},
+		{"Braise for forty five minutes.", []int{2700}},
+		{"Marinate for twelve hours.", []int{43200}},
+		{"Roast for an hour.", []int{3600}},
+		{"Let it rest a minute or so.", []int{60}},
+		{"Cook for an hour, then a further 10 minutes.", []int{3600, 600}},
+		// a lone second and vague phrasing are no durations
+		{"Whisk for a second, then pour.", nil},
+		{"Wait a few minutes.", nil},
+		{"Keep the pizza warm.", nil},
+	}
+	for _, test := range tests {
+		timers := FindTimers(test.text)
+		if len(timers) != len(test.seconds) {
+			t.Errorf("%q: timers %+v, want %v seconds", test.text, timers, test.seconds)
+			continue
+		}
+		for i, timer := range timers {
+			if timer.Seconds != test.seconds[i] {
+				t.Errorf("%q: timer %+v, want %d seconds", test.text, timer, test.seconds[i])
+			}
+		}
+	}
+}
+
+func TestFindTimersRange(t *testing.T) {
+	timers := FindTimers("Roast for twenty-five to thirty minutes.")
+	if len(timers) != 1 || timers[0].Seconds != 1500 || timers[0].MaxSeconds != 1800 {
+		t.Errorf("timers %+v, want 1500 to 1800 seconds", timers)
+	}
+}
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		instructions string
+		texts        []string
+	}{
+		{"1. Beat the eggs.\n2. Fry them.", []string{"Beat the eggs.", "Fry them."}},
+		{"STEP 1\nBeat the eggs.\nSTEP 2\nFry them.", []string{"Beat the eggs.", "Fry them."}},
+		{"Step 1: Beat the eggs.\r\n2) Fry them.", []string{"Beat the eggs.", "Fry them."}},
+		{"1 - Beat the eggs.\n2 – Fry them.", []string{"Beat the eggs.", "Fry them."}},
+		// numbers that start the step text are no markers
+		{"2-3 eggs, beaten.\nFry them.", []string{"2-3 eggs, beaten.", "Fry them."}},
+		{"1.5 kg potatoes, peeled.\nBoil them.", []string{"1.5 kg potatoes, peeled.", "Boil them."}},
+		{"1. 2 eggs, beaten.\nFry them.", []string{"1. 2 eggs, beaten.", "Fry them."}},
+		{"Beat the eggs. Fry them! Serve.", []string{"Beat the eggs.", "Fry them!", "Serve."}},
+	}
+	for _, test := range tests {
+		steps := ParseSteps(test.instructions)
+		var texts []string
+		for _, step := range steps {
+			texts = append(texts, step.Text)
+		}
+		if strings.Join(texts, "|") != strings.Join(test.texts, "|") {
+			t.Errorf("%q: steps %q, want %q", test.instructions, texts, test.texts)
+		}
+	}
+}
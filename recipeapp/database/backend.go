@@ -0,0 +1,104 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// DefaultDSN is the SQLite file used if no database is configured
+const DefaultDSN = "recipes.db"
+
+// Backend is a kind of database the app can store its data in, chosen by the DSN
+type Backend interface {
+	Name() string
+	Accepts(dsn string) bool
+	Dialector(dsn string) gorm.Dialector
+}
+
+// backends in the order they are asked to accept a DSN, SQLite takes everything that is no URL of another one
+var backends = []Backend{postgresBackend{}, sqliteBackend{}}
+
+type postgresBackend struct{}
+
+func (postgresBackend) Name() string {
+	return "postgres"
+}
+
+// Accepts postgres:// and postgresql:// URLs
+func (postgresBackend) Accepts(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+func (postgresBackend) Dialector(dsn string) gorm.Dialector {
+	return postgres.Open(dsn)
+}
+
+type sqliteBackend struct{}
+
+func (sqliteBackend) Name() string {
+	return "sqlite"
+}
+
+// Accepts file paths, optionally prefixed with sqlite://
+func (sqliteBackend) Accepts(dsn string) bool {
+	return true
+}
+
+// Dialector opens the file with foreign keys enforced
+func (sqliteBackend) Dialector(dsn string) gorm.Dialector {
+	dsn = strings.TrimPrefix(dsn, "sqlite://")
+	if strings.Contains(dsn, "?") {
+		return sqlite.Open(dsn + "&_foreign_keys=on")
+	}
+	return sqlite.Open(dsn + "?_foreign_keys=on")
+}
+
+// BackendFor returns the backend that stores the data of a DSN
+func BackendFor(dsn string) Backend {
+	for _, backend := range backends {
+		if backend.Accepts(dsn) {
+			return backend
+		}
+	}
+	return sqliteBackend{}
+}
+
+// Config selects the database and sizes its connection pool, zero values keep the defaults of database/sql
+type Config struct {
+	DSN             string // SQLite file or postgres:// URL, DefaultDSN if empty
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// Open connects to the database of the config
+func Open(config Config) (*gorm.DB, error) {
+	if config.DSN == "" {
+		config.DSN = DefaultDSN
+	}
+	db, err := gorm.Open(BackendFor(config.DSN).Dialector(config.DSN), &gorm.Config{Logger: queryLogger{}})
+	if err != nil {
+		return nil, err
+	}
+	if err := observeQueries(db); err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	return db, nil
+}
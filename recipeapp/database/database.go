@@ -3,21 +3,39 @@ package database
 import (
 	"database/sql/driver"
 	"encoding/json"
-	"errors"
 	"recipeapp/models"
+	"recipeapp/planner"
+	"time"
 
 	"github.com/google/uuid"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-var db *gorm.DB
-
 type MealsJSON []models.Meal
 
+type ScheduleJSON planner.Schedule
+
+// Sources of a plan
+const (
+	SourceGenerated = "generated" // generated on request
+	SourceTemplate  = "template"  // a template applied to a week
+	SourceScheduled = "scheduled" // generated by a recurring rule
+)
+
+// RecipesEntry is a plan with its meals in order, stored in the plans and plan_items tables
 type RecipesEntry struct {
-	EntryUUID uuid.UUID `gorm:"primaryKey"`
-	Meals     MealsJSON `gorm:"type:json"`
+	EntryUUID   uuid.UUID
+	UserUUID    uuid.UUID // nil for plans from before users were known
+	Meals       MealsJSON
+	Schedule    ScheduleJSON // empty for plans where every meal is cooked on its own day
+	WeekStart   time.Time    // first day of the plan, zero if the plan starts when it is created
+	Source      string
+	Seed        *int64      // seed the plan was generated with, nil for templates and plans from before seeds were kept
+	Options     string      // encoded options the plan was generated with
+	Fingerprint string      // hash of the candidates and preferences the plan was generated from
+	Candidates  StringsJSON // ids of the cached meals the plan was drawn from, in their order
+	Discoveries StringsJSON // ids of the meals discovered at TheMealDB while generating, in their order
+	CreatedAt   time.Time
 }
 
 // Value marshals the MealsJSON slice into a JSON byte array for database storage
@@ -27,52 +45,48 @@ func (m MealsJSON) Value() (driver.Value, error) {
 
 // Scan unmarshals JSON data from the database back into a MealsJSON slice
 func (m *MealsJSON) Scan(value interface{}) error {
-	bytes, ok := value.([]byte)
+	bytes, ok := jsonBytes(value)
 	if !ok {
 		return nil
 	}
 	return json.Unmarshal(bytes, m)
 }
 
-// ConnectToSQLite opens (or creates) the SQLite database and returns the DB instance
-func ConnectToSQLite() (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open("recipes.db"), &gorm.Config{})
-	if err != nil {
-		return nil, err
-	}
-	return db, nil
+// Value marshals the ScheduleJSON into a JSON byte array for database storage
+func (s ScheduleJSON) Value() (driver.Value, error) {
+	return json.Marshal(s)
 }
 
-// CreateEntry creates a new RecipesEntry in the database and returns its UUID
-func CreateEntry(db *gorm.DB, response []models.Meal) (uuid.UUID, error) {
-	entryUUID := uuid.New()
-	meals := response
-	entry := RecipesEntry{
-		EntryUUID: entryUUID,
-		Meals:     meals,
-	}
-	if err := db.Create(&entry).Error; err != nil {
-		return uuid.Nil, err
+// Scan unmarshals JSON data from the database back into a ScheduleJSON
+func (s *ScheduleJSON) Scan(value interface{}) error {
+	bytes, ok := jsonBytes(value)
+	if !ok {
+		return nil
 	}
-	return entryUUID, nil
+	return json.Unmarshal(bytes, s)
 }
 
-// GetRecipesFromDBByUUID retrieves a RecipesEntry by UUID and returns its Meals slice
-func GetRecipesFromDBByUUID(db *gorm.DB, id uuid.UUID) ([]models.Meal, error) {
-	var entry RecipesEntry
-	if err := db.First(&entry, "entry_uuid = ?", id).Error; err != nil {
-		return nil, err
+// jsonBytes returns the JSON of a column value, SQLite returns what was written as bytes and PostgreSQL as text
+func jsonBytes(value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case []byte:
+		return v, true
+	case string:
+		return []byte(v), true
 	}
-	return entry.Meals, nil
+	return nil, false
 }
 
-func SetDB(database *gorm.DB) {
-	db = database
+// ErrNotFound is returned for records that do not exist
+var ErrNotFound = gorm.ErrRecordNotFound
+
+// Repository stores and loads the data of the app, handlers use it instead of the database connection
+type Repository struct {
+	db  *gorm.DB
+	fts bool // the full-text index is available, see InitSearch
 }
 
-func GetDB() (*gorm.DB, error) {
-	if db == nil {
-		return nil, errors.New("database connection is nil")
-	}
-	return db, nil
+// NewRepository returns a repository on the database connection
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
 }
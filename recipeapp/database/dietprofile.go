@@ -0,0 +1,26 @@
+package database
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// DietProfile holds the dietary restrictions of a user
+type DietProfile struct {
+	UserUUID            uuid.UUID   `gorm:"primaryKey"`
+	Diets               StringsJSON `gorm:"type:json"`
+	ExcludedIngredients StringsJSON `gorm:"type:json"`
+}
+
+// GetDietProfile returns the diet profile of a user, an empty profile if the user has none
+func (r *Repository) GetDietProfile(ctx context.Context, user uuid.UUID) (DietProfile, error) {
+	profile := DietProfile{UserUUID: user}
+	err := r.db.WithContext(ctx).Where("user_uuid = ?", user).Limit(1).Find(&profile).Error
+	return profile, err
+}
+
+// SaveDietProfile creates or replaces the diet profile of a user
+func (r *Repository) SaveDietProfile(ctx context.Context, profile DietProfile) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&profile).Error
+}
@@ -0,0 +1,15 @@
+//go:build sqlite_fts5
+
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestInitSearchUsesFTS5(t *testing.T) {
+	repo := openTestRepository(t, filepath.Join(t.TempDir(), "recipes.db"), nil)
+	if !repo.fts {
+		t.Error("search scans the cache although SQLite was built with FTS5")
+	}
+}
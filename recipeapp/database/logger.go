@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"recipeapp/logging"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQuery is the duration from which queries are logged as warnings
+const slowQuery = 200 * time.Millisecond
+
+// queryLogger logs the queries of gorm with the request id of their context.
+// Queries are logged at level debug, failed ones at level error and slow ones at level warn.
+// Missing records are no failure, the repository reports them as ErrNotFound.
+// The values of queries are never logged, user and plan ids are also the tokens of the cookies.
+type queryLogger struct{}
+
+// ParamsFilter leaves the placeholders of the values in the logged queries
+func (queryLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (l queryLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (queryLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Info(fmt.Sprintf(msg, args...))
+}
+
+func (queryLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Warn(fmt.Sprintf(msg, args...))
+}
+
+func (queryLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Error(fmt.Sprintf(msg, args...))
+}
+
+func (queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	log := logging.FromContext(ctx)
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level = slog.LevelError
+	case elapsed >= slowQuery:
+		level = slog.LevelWarn
+	}
+	if !log.Enabled(ctx, level) {
+		return
+	}
+	sql, rows := fc()
+	attrs := []any{"sql", sql, "rows", rows, "duration", elapsed}
+	if level == slog.LevelError {
+		attrs = append(attrs, "error", err)
+	}
+	log.Log(ctx, level, "query", attrs...)
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"recipeapp/metrics"
+	"recipeapp/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mealRow is a meal of the meals table. Meals from TheMealDB have no owner and are the cached meals,
+// own recipes are stored here with their owner.
+type mealRow struct {
+	IdMeal                   string `gorm:"primaryKey"`
+	OwnerUUID                *uuid.UUID
+	Name                     string
+	Alternate                string
+	Category                 string
+	Area                     string
+	Instructions             string
+	Thumb                    string
+	Tags                     string
+	Youtube                  string
+	Source                   string
+	ImageSource              string
+	CreativeCommonsConfirmed string
+	DateModified             string
+	UpdatedAt                time.Time
+	Ingredients              []ingredientRow `gorm:"foreignKey:IdMeal"`
+}
+
+func (mealRow) TableName() string {
+	return "meals"
+}
+
+// ingredientRow is an ingredient of a meal at its position in the meal
+type ingredientRow struct {
+	IdMeal   string `gorm:"primaryKey"`
+	Position int    `gorm:"primaryKey;autoIncrement:false"`
+	Name     string
+	Measure  string
+}
+
+func (ingredientRow) TableName() string {
+	return "ingredients"
+}
+
+func toMealRow(meal models.Meal, owner *uuid.UUID) mealRow {
+	row := mealRow{
+		IdMeal:                   meal.IdMeal,
+		OwnerUUID:                owner,
+		Name:                     meal.StrMeal,
+		Alternate:                meal.StrMealAlternate,
+		Category:                 meal.StrCategory,
+		Area:                     meal.StrArea,
+		Instructions:             meal.StrInstructions,
+		Thumb:                    meal.StrMealThumb,
+		Tags:                     meal.StrTags,
+		Youtube:                  meal.StrYoutube,
+		Source:                   meal.StrSource,
+		ImageSource:              meal.StrImageSource,
+		CreativeCommonsConfirmed: meal.StrCreativeCommonsConfirmed,
+		DateModified:             meal.DateModified,
+	}
+	for i, ingredient := range meal.Ingredients() {
+		row.Ingredients = append(row.Ingredients, ingredientRow{
+			IdMeal:   meal.IdMeal,
+			Position: i + 1,
+			Name:     ingredient.Name,
+			Measure:  ingredient.Measure,
+		})
+	}
+	return row
+}
+
+func (row mealRow) meal() models.Meal {
+	meal := models.Meal{
+		IdMeal:                      row.IdMeal,
+		StrMeal:                     row.Name,
+		StrMealAlternate:            row.Alternate,
+		StrCategory:                 row.Category,
+		StrArea:                     row.Area,
+		StrInstructions:             row.Instructions,
+		StrMealThumb:                row.Thumb,
+		StrTags:                     row.Tags,
+		StrYoutube:                  row.Youtube,
+		StrSource:                   row.Source,
+		StrImageSource:              row.ImageSource,
+		StrCreativeCommonsConfirmed: row.CreativeCommonsConfirmed,
+		DateModified:                row.DateModified,
+	}
+	ingredients := make([]models.Ingredient, 0, len(row.Ingredients))
+	for _, ingredient := range row.Ingredients {
+		ingredients = append(ingredients, models.Ingredient{Name: ingredient.Name, Measure: ingredient.Measure})
+	}
+	meal.SetIngredients(ingredients)
+	return meal
+}
+
+// catalogueMeals restricts meal rows to the meals from TheMealDB
+const catalogueMeals = "owner_uuid IS NULL AND id_meal NOT LIKE '" + UserRecipePrefix + "%'"
+
+// withIngredients loads the ingredients of meal rows in their order
+func withIngredients(db *gorm.DB) *gorm.DB {
+	return db.Preload("Ingredients", func(db *gorm.DB) *gorm.DB { return db.Order("position") })
+}
+
+// saveMeals stores meals with their ingredients. Own recipes get the owner, existing meals are only replaced
+// with replace so older copies, e.g. from templates, do not overwrite fresher ones.
+func saveMeals(tx *gorm.DB, meals []models.Meal, owner uuid.UUID, replace bool) error {
+	for _, meal := range meals {
+		if meal.IdMeal == "" {
+			continue
+		}
+		var mealOwner *uuid.UUID
+		if IsUserRecipeID(meal.IdMeal) {
+			mealOwner = &owner
+		}
+		row := toMealRow(meal, mealOwner)
+		conflict := clause.OnConflict{DoNothing: true}
+		if replace {
+			conflict = clause.OnConflict{UpdateAll: true}
+		}
+		result := tx.Omit(clause.Associations).Clauses(conflict).Create(&row)
+		if result.Error != nil {
+			return result.Error
+		}
+		if !replace && result.RowsAffected == 0 {
+			continue
+		}
+		if err := tx.Where("id_meal = ?", row.IdMeal).Delete(&ingredientRow{}).Error; err != nil {
+			return err
+		}
+		if len(row.Ingredients) > 0 {
+			if err := tx.Create(&row.Ingredients).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cachedMeals returns the meals from TheMealDB with the given ids
+func cachedMeals(db *gorm.DB, ids []string) (map[string]models.Meal, error) {
+	var rows []mealRow
+	if err := withIngredients(db).Where("id_meal IN ?", ids).Where(catalogueMeals).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	meals := make(map[string]models.Meal, len(rows))
+	for _, row := range rows {
+		meals[row.IdMeal] = row.meal()
+	}
+	return meals, nil
+}
+
+// allCachedMeals returns every meal from TheMealDB ordered by id
+func allCachedMeals(db *gorm.DB) ([]models.Meal, error) {
+	var rows []mealRow
+	if err := withIngredients(db).Where(catalogueMeals).Order("id_meal").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	meals := make([]models.Meal, 0, len(rows))
+	for _, row := range rows {
+		meals = append(meals, row.meal())
+	}
+	return meals, nil
+}
+
+// CacheMeals stores or refreshes meals in the cache, the search index and the tags, user-owned recipes are skipped
+func (r *Repository) CacheMeals(ctx context.Context, meals []models.Meal) error {
+	cached := make([]models.Meal, 0, len(meals))
+	for _, meal := range meals {
+		if meal.IdMeal != "" && !IsUserRecipeID(meal.IdMeal) {
+			cached = append(cached, meal)
+		}
+	}
+	if len(cached) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveMeals(tx, cached, uuid.Nil, true); err != nil {
+			return err
+		}
+		if err := tagMeals(tx, cached); err != nil {
+			return err
+		}
+		return r.indexMeals(tx, cached)
+	})
+}
+
+// GetCachedMeals returns the cached meals with the given ids, ids that are not cached are left out.
+// The lookups are counted as cache hits and misses.
+func (r *Repository) GetCachedMeals(ctx context.Context, ids []string) (map[string]models.Meal, error) {
+	meals, err := cachedMeals(r.db.WithContext(ctx), ids)
+	if err != nil {
+		return nil, err
+	}
+	metrics.MealCacheLookups.WithLabelValues("hit").Add(float64(len(meals)))
+	metrics.MealCacheLookups.WithLabelValues("miss").Add(float64(len(ids) - len(meals)))
+	return meals, nil
+}
+
+// GetAllCachedMeals returns every cached meal ordered by id
+func (r *Repository) GetAllCachedMeals(ctx context.Context) ([]models.Meal, error) {
+	return allCachedMeals(r.db.WithContext(ctx))
+}
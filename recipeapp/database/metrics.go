@@ -0,0 +1,49 @@
+package database
+
+import (
+	"errors"
+	"recipeapp/metrics"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// queryStartKey is where the start of a query is kept for the duration metric
+const queryStartKey = "metrics:query_start"
+
+// observeQueries times every query of db by operation and table
+func observeQueries(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("*").Register("metrics:before_create", startQuery),
+		callbacks.Create().After("*").Register("metrics:after_create", finishQuery("create")),
+		callbacks.Query().Before("*").Register("metrics:before_query", startQuery),
+		callbacks.Query().After("*").Register("metrics:after_query", finishQuery("query")),
+		callbacks.Update().Before("*").Register("metrics:before_update", startQuery),
+		callbacks.Update().After("*").Register("metrics:after_update", finishQuery("update")),
+		callbacks.Delete().Before("*").Register("metrics:before_delete", startQuery),
+		callbacks.Delete().After("*").Register("metrics:after_delete", finishQuery("delete")),
+		callbacks.Row().Before("*").Register("metrics:before_row", startQuery),
+		callbacks.Row().After("*").Register("metrics:after_row", finishQuery("row")),
+		callbacks.Raw().Before("*").Register("metrics:before_raw", startQuery),
+		callbacks.Raw().After("*").Register("metrics:after_raw", finishQuery("raw")),
+	)
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func finishQuery(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		metrics.DBQueryDuration.WithLabelValues(operation, db.Statement.Table).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a numbered change of the schema, Down reverts what Up did
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// MigrationStep is a migration to apply, or to revert if Down is set
+type MigrationStep struct {
+	Migration
+	Down bool
+}
+
+func (s MigrationStep) String() string {
+	direction := "up"
+	if s.Down {
+		direction = "down"
+	}
+	return fmt.Sprintf("%d %s (%s)", s.Version, s.Name, direction)
+}
+
+// SchemaVersion records an applied migration
+type SchemaVersion struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// LatestVersion returns the version of the newest migration
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// CurrentVersion returns the version of the last applied migration, 0 for a database without any
+func CurrentVersion(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&SchemaVersion{}) {
+		return 0, nil
+	}
+	var version int
+	err := db.Model(&SchemaVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Migrate brings the schema to the latest version, see MigrateTo
+func Migrate(db *gorm.DB, dryRun bool) ([]MigrationStep, error) {
+	return MigrateTo(db, LatestVersion(), dryRun)
+}
+
+// migrationLockKey is the PostgreSQL advisory lock held while migrating
+const migrationLockKey = 7305826104
+
+// MigrateTo applies or reverts migrations until the schema has the target version and returns the steps taken.
+// Each step runs in its own transaction together with its schema_version record, a failed step stops the
+// migration at the version before it. With dryRun nothing is changed and the steps that would be taken are returned.
+// On PostgreSQL replicas starting at once take turns: each holds an advisory lock while it migrates, and the
+// ones after the first find the schema up to date.
+func MigrateTo(db *gorm.DB, target int, dryRun bool) ([]MigrationStep, error) {
+	if target < 0 || target > LatestVersion() {
+		return nil, fmt.Errorf("unknown schema version %d, the latest is %d", target, LatestVersion())
+	}
+	if dryRun || db.Name() != "postgres" {
+		return migrateTo(db, target, dryRun)
+	}
+	var steps []MigrationStep
+	err := db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+		var err error
+		steps, err = migrateTo(conn, target, dryRun)
+		return err
+	})
+	return steps, err
+}
+
+// migrateTo is MigrateTo on a single connection
+func migrateTo(db *gorm.DB, target int, dryRun bool) ([]MigrationStep, error) {
+	if !dryRun && !db.Migrator().HasTable(&SchemaVersion{}) {
+		if err := db.Migrator().CreateTable(&SchemaVersion{}); err != nil {
+			return nil, err
+		}
+	}
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []MigrationStep
+	if target >= current {
+		for _, m := range migrations {
+			if m.Version > current && m.Version <= target {
+				steps = append(steps, MigrationStep{Migration: m})
+			}
+		}
+	} else {
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if m := migrations[i]; m.Version <= current && m.Version > target {
+				steps = append(steps, MigrationStep{Migration: m, Down: true})
+			}
+		}
+	}
+	if dryRun {
+		return steps, nil
+	}
+	for i, step := range steps {
+		if err := db.Transaction(step.run); err != nil {
+			return steps[:i], fmt.Errorf("migration %s: %w", step, err)
+		}
+	}
+	return steps, nil
+}
+
+// run applies or reverts the migration and records it
+func (s MigrationStep) run(tx *gorm.DB) error {
+	if s.Down {
+		if err := s.Migration.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaVersion{}, s.Version).Error
+	}
+	if err := s.Up(tx); err != nil {
+		return err
+	}
+	return tx.Create(&SchemaVersion{Version: s.Version, Name: s.Name, AppliedAt: time.Now()}).Error
+}
+
+// dialectSQL adapts a raw migration statement written for SQLite to the database of the transaction
+func dialectSQL(tx *gorm.DB, statement string) string {
+	if tx.Name() == "postgres" {
+		return strings.ReplaceAll(statement, "TIMESTAMP", "TIMESTAMPTZ")
+	}
+	return statement
+}
+
+// createTables creates the tables that do not exist yet, databases from before the migrations already have some
+func createTables(tx *gorm.DB, tables ...interface{}) error {
+	for _, table := range tables {
+		if tx.Migrator().HasTable(table) {
+			continue
+		}
+		if err := tx.Migrator().CreateTable(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropTables(tx *gorm.DB, tables ...interface{}) error {
+	for _, table := range tables {
+		if err := tx.Migrator().DropTable(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumns adds the columns of the fields that do not exist yet, with their indexes
+func addColumns(tx *gorm.DB, table interface{}, fields ...string) error {
+	for _, field := range fields {
+		if tx.Migrator().HasColumn(table, field) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(table, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropColumns(tx *gorm.DB, table interface{}, fields ...string) error {
+	for _, field := range fields {
+		if !tx.Migrator().HasColumn(table, field) {
+			continue
+		}
+		if err := tx.Migrator().DropColumn(table, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,477 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"recipeapp/models"
+	"recipeapp/planner"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// baselineDB is the database file as shipped before the migrations, with only the plans table
+const baselineDB = "../recipes.db"
+
+// openBaselineCopy opens a copy of the baseline database with an old plan in it
+func openBaselineCopy(t *testing.T) (*gorm.DB, uuid.UUID) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "recipes.db")
+	src, err := os.Open(baselineDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	dst, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := gorm.Open(sqlite.Open(path+"?_foreign_keys=on"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := uuid.New()
+	err = db.Exec("INSERT INTO recipes_entries (entry_uuid, meals) VALUES (?, ?)",
+		id.String(), []byte(`[{"idMeal":"52772","strMeal":"Teriyaki Chicken Casserole"}]`)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, id
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	ctx := context.Background()
+	db, id := openBaselineCopy(t)
+
+	steps, err := Migrate(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != len(migrations) {
+		t.Errorf("applied %d migrations, want %d", len(steps), len(migrations))
+	}
+	if version, err := CurrentVersion(db); err != nil || version != LatestVersion() {
+		t.Errorf("version %d (%v), want %d", version, err, LatestVersion())
+	}
+
+	entry, err := NewRepository(db).GetEntryByUUID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Meals) != 1 || entry.Meals[0].StrMeal != "Teriyaki Chicken Casserole" {
+		t.Errorf("meals %v after migration", entry.Meals)
+	}
+	if entry.Source != SourceGenerated {
+		t.Errorf("source %q, want %q", entry.Source, SourceGenerated)
+	}
+	if entry.UserUUID != uuid.Nil || entry.Seed != nil {
+		t.Errorf("old plan got owner %v and seed %v", entry.UserUUID, entry.Seed)
+	}
+
+	if steps, err := Migrate(db, false); err != nil || len(steps) != 0 {
+		t.Errorf("second migration applied %v (%v)", steps, err)
+	}
+}
+
+// TestMigrationsMatchModels checks that the migrated schema has a column for every field of the models
+func TestMigrationsMatchModels(t *testing.T) {
+	db, _ := openBaselineCopy(t)
+	if _, err := Migrate(db, false); err != nil {
+		t.Fatal(err)
+	}
+	models := []interface{}{&planRow{}, &planItemRow{}, &mealRow{}, &ingredientRow{}, &StoreLayout{}, &IngredientSection{},
+		&UserRecipe{}, &MealRating{}, &DietProfile{}, &Pantry{}, &IngredientPrice{}, &Tag{}, &MealTag{}, &templateRow{},
+		&templateItemRow{}, &RecurringRule{}}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatal(err)
+		}
+		if !db.Migrator().HasTable(model) {
+			t.Errorf("table %s missing", stmt.Schema.Table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("column %s.%s missing", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	db, _ := openBaselineCopy(t)
+
+	steps, err := Migrate(db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != len(migrations) {
+		t.Errorf("%d pending migrations, want %d", len(steps), len(migrations))
+	}
+	if db.Migrator().HasTable(&SchemaVersion{}) || db.Migrator().HasTable(&templateRow{}) {
+		t.Error("dry run changed the schema")
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	ctx := context.Background()
+	db, id := openBaselineCopy(t)
+	if _, err := Migrate(db, false); err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := MigrateTo(db, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != len(migrations)-1 || !steps[0].Down || steps[0].Version != LatestVersion() {
+		t.Errorf("rolled back with %v", steps)
+	}
+	if db.Migrator().HasTable(&templateRow{}) || db.Migrator().HasColumn("recipes_entries", "user_uuid") {
+		t.Error("rollback left newer tables or columns")
+	}
+	if version, _ := CurrentVersion(db); version != 1 {
+		t.Errorf("version %d after rollback, want 1", version)
+	}
+	var count int64
+	if err := db.Table("recipes_entries").Where("entry_uuid = ?", id.String()).Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("plan lost in rollback: %d (%v)", count, err)
+	}
+
+	if _, err := Migrate(db, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRepository(db).GetEntryByUUID(ctx, id); err != nil {
+		t.Errorf("plan after migrating up again: %v", err)
+	}
+	if _, err := MigrateTo(db, LatestVersion()+1, false); err == nil {
+		t.Error("migrated to an unknown version")
+	}
+}
+
+// TestSplitPlans checks that the meals of the cache and of the plans end up in the meals table and back
+func TestSplitPlans(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openBaselineCopy(t)
+	if _, err := MigrateTo(db, 11, false); err != nil {
+		t.Fatal(err)
+	}
+	user := uuid.New()
+	cached := models.Meal{IdMeal: "52772", StrMeal: "Teriyaki Chicken Casserole", StrCategory: "Chicken",
+		StrIngredient1: "soy sauce", StrMeasure1: "3/4 cup", StrIngredient2: "water", StrMeasure2: "1/2 cup"}
+	own := models.Meal{IdMeal: UserRecipePrefix + uuid.NewString(), StrMeal: "Grandmas Soup", StrIngredient1: "leek"}
+	schedule := planner.Schedule{{Kind: planner.Cook}, {Kind: planner.Leftovers, LeftoversOf: 1}, {Kind: planner.Cook}}
+	cachedJSON, _ := json.Marshal(cached)
+	mealsJSON, _ := json.Marshal([]models.Meal{cached, own})
+	scheduleJSON, _ := json.Marshal(schedule)
+	id := uuid.New()
+	err := db.Exec("INSERT INTO cached_meals (id_meal, meal) VALUES (?, ?)", cached.IdMeal, cachedJSON).Error
+	if err == nil {
+		err = db.Exec("INSERT INTO recipes_entries (entry_uuid, user_uuid, meals, schedule, source) VALUES (?, ?, ?, ?, ?)",
+			id.String(), user.String(), mealsJSON, scheduleJSON, SourceTemplate).Error
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(db, false); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewRepository(db)
+	entry, err := repo.GetEntryByUUID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.UserUUID != user || entry.Source != SourceTemplate || len(entry.Schedule) != 3 {
+		t.Errorf("plan %+v after splitting", entry)
+	}
+	if len(entry.Meals) != 2 || entry.Meals[0] != cached || entry.Meals[1] != own {
+		t.Errorf("meals %+v after splitting", entry.Meals)
+	}
+	all, err := repo.GetAllCachedMeals(ctx)
+	if err != nil || len(all) != 1 || all[0] != cached {
+		t.Errorf("cached meals %+v (%v), want only the meal from TheMealDB", all, err)
+	}
+	var ingredients int64
+	db.Table("ingredients").Where("id_meal = ?", cached.IdMeal).Count(&ingredients)
+	if ingredients != 2 {
+		t.Errorf("%d ingredients stored, want 2", ingredients)
+	}
+
+	if _, err := MigrateTo(db, 11, false); err != nil {
+		t.Fatal(err)
+	}
+	var restored struct {
+		Meals []byte
+	}
+	if err := db.Table("recipes_entries").Where("entry_uuid = ?", id.String()).Take(&restored).Error; err != nil {
+		t.Fatal(err)
+	}
+	var meals []models.Meal
+	if err := json.Unmarshal(restored.Meals, &meals); err != nil || len(meals) != 2 || meals[0] != cached {
+		t.Errorf("restored meals %+v (%v)", meals, err)
+	}
+	if db.Migrator().HasTable("meals") {
+		t.Error("meals table left after reverting")
+	}
+}
+
+// TestSplitTemplates checks that the meals of templates end up in the meals table and back
+func TestSplitTemplates(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openBaselineCopy(t)
+	if _, err := MigrateTo(db, 12, false); err != nil {
+		t.Fatal(err)
+	}
+	owner := uuid.New()
+	cached := models.Meal{IdMeal: "52982", StrMeal: "Spaghetti alla Carbonara", StrIngredient1: "spaghetti",
+		StrMeasure1: "320g"}
+	own := models.Meal{IdMeal: UserRecipePrefix + uuid.NewString(), StrMeal: "Grandmas Soup", StrIngredient1: "leek"}
+	mealsJSON, _ := json.Marshal([]models.Meal{cached, own})
+	id := uuid.New()
+	err := db.Exec("INSERT INTO plan_templates (template_uuid, owner_uuid, name, meals, schedule) VALUES (?, ?, ?, ?, ?)",
+		id.String(), owner.String(), "Winter week A", mealsJSON, "[]").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(db, false); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasColumn("plan_templates", "meals") {
+		t.Error("meals column left after splitting")
+	}
+	if !db.Migrator().HasIndex(&templateRow{}, "OwnerUUID") {
+		t.Error("owner index of plan_templates lost")
+	}
+	repo := NewRepository(db)
+	template, err := repo.GetTemplate(ctx, owner, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if template.Name != "Winter week A" || len(template.Meals) != 2 || template.Meals[0] != cached ||
+		template.Meals[1] != own {
+		t.Errorf("template %+v after splitting", template)
+	}
+	var owned int64
+	db.Table("meals").Where("id_meal = ? AND owner_uuid = ?", own.IdMeal, owner.String()).Count(&owned)
+	if owned != 1 {
+		t.Error("own recipe of the template stored without its owner")
+	}
+
+	if _, err := MigrateTo(db, 12, false); err != nil {
+		t.Fatal(err)
+	}
+	var restored struct {
+		Meals []byte
+	}
+	if err := db.Table("plan_templates").Where("template_uuid = ?", id.String()).Take(&restored).Error; err != nil {
+		t.Fatal(err)
+	}
+	var meals []models.Meal
+	if err := json.Unmarshal(restored.Meals, &meals); err != nil || len(meals) != 2 || meals[1] != own {
+		t.Errorf("restored meals %+v (%v)", meals, err)
+	}
+	if db.Migrator().HasTable("template_items") {
+		t.Error("template_items table left after reverting")
+	}
+}
+
+// TestSectionOwners checks that shared store layouts and sections are kept under the nil user and restored
+func TestSectionOwners(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openBaselineCopy(t)
+	if _, err := MigrateTo(db, 13, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO ingredient_sections (ingredient, section) VALUES (?, ?)", "leek", "frozen").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(db, false); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewRepository(db)
+	if sections, err := repo.GetIngredientSections(ctx, uuid.New()); err != nil || len(sections) != 0 {
+		t.Errorf("sections %v (%v) of a user, want the shared ones left out", sections, err)
+	}
+	if sections, err := repo.GetIngredientSections(ctx, uuid.Nil); err != nil || sections["leek"] != "frozen" {
+		t.Errorf("shared sections %v (%v) after migrating", sections, err)
+	}
+
+	if _, err := MigrateTo(db, 13, false); err != nil {
+		t.Fatal(err)
+	}
+	var section string
+	if err := db.Table("ingredient_sections").Where("ingredient = ?", "leek").Select("section").Scan(&section).Error; err != nil || section != "frozen" {
+		t.Errorf("section %q (%v) after reverting", section, err)
+	}
+}
+
+// TestPlanSnapshotColumns checks that rolling back the candidates and discoveries keeps the items and index of plans
+func TestPlanSnapshotColumns(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openBaselineCopy(t)
+	if _, err := Migrate(db, false); err != nil {
+		t.Fatal(err)
+	}
+	meal := models.Meal{IdMeal: "52982", StrMeal: "Spaghetti alla Carbonara"}
+	entry := RecipesEntry{UserUUID: uuid.New(), Meals: MealsJSON{meal}, Candidates: StringsJSON{meal.IdMeal}}
+	id, err := NewRepository(db).CreateEntry(ctx, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateTo(db, 14, false); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasColumn("plans", "candidates") || db.Migrator().HasColumn("plans", "discoveries") {
+		t.Error("rollback left the snapshot columns")
+	}
+	if !db.Migrator().HasIndex("plans", "idx_plans_user_uuid") {
+		t.Error("rollback lost the user index of plans")
+	}
+	var items int64
+	if err := db.Table("plan_items").Where("plan_uuid = ?", id.String()).Count(&items).Error; err != nil || items != 1 {
+		t.Errorf("%d plan items (%v) after the rollback, want 1", items, err)
+	}
+
+	if _, err := Migrate(db, false); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := NewRepository(db).GetEntryByUUID(ctx, id); err != nil || len(stored.Meals) != 1 {
+		t.Errorf("plan %+v (%v) after migrating up again", stored, err)
+	}
+}
+
+// TestOwnRecipesInMeals checks that own recipes move into the meals table, replacing planned copies, and back
+func TestOwnRecipesInMeals(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openBaselineCopy(t)
+	if _, err := MigrateTo(db, 15, false); err != nil {
+		t.Fatal(err)
+	}
+	owner := uuid.New()
+	planned := models.Meal{IdMeal: UserRecipePrefix + uuid.NewString(), StrMeal: "Grandmas Soup", StrIngredient1: "leek",
+		StrMeasure1: "2"}
+	unplanned := models.Meal{IdMeal: UserRecipePrefix + uuid.NewString(), StrMeal: "Grandmas Pie", StrTags: "Baking"}
+	plan := uuid.New()
+	err := db.Exec("INSERT INTO meals (id_meal, owner_uuid, name) VALUES (?, ?, ?)",
+		planned.IdMeal, owner.String(), "Old Soup").Error
+	if err == nil {
+		err = db.Exec("INSERT INTO plans (plan_uuid, user_uuid) VALUES (?, ?)", plan.String(), owner.String()).Error
+	}
+	if err == nil {
+		err = db.Exec("INSERT INTO plan_items (plan_uuid, position, id_meal) VALUES (?, 1, ?)",
+			plan.String(), planned.IdMeal).Error
+	}
+	for i, meal := range []models.Meal{planned, unplanned} {
+		if err == nil {
+			data, _ := json.Marshal(meal)
+			err = db.Exec("INSERT INTO user_recipes (id_meal, owner_uuid, meal, created_at) VALUES (?, ?, ?, ?)",
+				meal.IdMeal, owner.String(), data, time.Now().Add(time.Duration(i)*time.Minute)).Error
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(db, false); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasColumn("user_recipes", "meal") {
+		t.Error("meal column left in user_recipes")
+	}
+	if !db.Migrator().HasIndex(&UserRecipe{}, "OwnerUUID") {
+		t.Error("owner index of user_recipes lost")
+	}
+	repo := NewRepository(db)
+	recipes, err := repo.GetUserRecipes(ctx, owner)
+	if err != nil || len(recipes) != 2 || recipes[0] != planned || recipes[1] != unplanned {
+		t.Errorf("recipes %+v (%v) after moving them", recipes, err)
+	}
+	if meals, err := repo.GetRecipesFromDBByUUID(ctx, plan); err != nil || len(meals) != 1 || meals[0] != planned {
+		t.Errorf("plan meals %+v (%v), want the recipe instead of the old copy", meals, err)
+	}
+	var owned int64
+	db.Table("meals").Where("owner_uuid = ?", owner.String()).Count(&owned)
+	if owned != 2 {
+		t.Errorf("%d meals stored with the owner, want 2", owned)
+	}
+
+	if _, err := MigrateTo(db, 15, false); err != nil {
+		t.Fatal(err)
+	}
+	var restored struct {
+		Meal []byte
+	}
+	if err := db.Table("user_recipes").Where("id_meal = ?", unplanned.IdMeal).Take(&restored).Error; err != nil {
+		t.Fatal(err)
+	}
+	var meal models.Meal
+	if err := json.Unmarshal(restored.Meal, &meal); err != nil || meal != unplanned {
+		t.Errorf("restored recipe %+v (%v)", meal, err)
+	}
+}
+
+func TestMigrateConcurrentlyOnPostgres(t *testing.T) {
+	dsn := os.Getenv(postgresTestDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresTestDSN)
+	}
+	if err := openPostgres(t, dsn).Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// replicas starting at once
+	results := make(chan int, 3)
+	for range 3 {
+		db := openPostgres(t, dsn)
+		go func() {
+			steps, err := Migrate(db, false)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- len(steps)
+		}()
+	}
+	total := 0
+	for range 3 {
+		total += <-results
+	}
+	if total != len(migrations) {
+		t.Errorf("%d migrations applied by the replicas, want %d", total, len(migrations))
+	}
+}
+
+// openPostgres opens a connection pool of its own to the database
+func openPostgres(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := Open(Config{DSN: dsn, MaxOpenConns: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = db.Session(&gorm.Session{Logger: logger.Discard})
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
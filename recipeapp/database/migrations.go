@@ -0,0 +1,852 @@
+package database
+
+import (
+	"encoding/json"
+	"recipeapp/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// The tables as the migrations create them. They are frozen copies of the models at the time of the migration,
+// later changes to the models need a new migration instead of a change here.
+
+type recipesEntryV1 struct {
+	EntryUUID string `gorm:"primaryKey"`
+	Meals     string `gorm:"type:json"`
+}
+
+func (recipesEntryV1) TableName() string { return "recipes_entries" }
+
+type storeLayoutV2 struct {
+	Store      string `gorm:"primaryKey"`
+	AisleOrder string `gorm:"type:json"`
+}
+
+func (storeLayoutV2) TableName() string { return "store_layouts" }
+
+type ingredientSectionV2 struct {
+	Ingredient string `gorm:"primaryKey"`
+	Section    string
+}
+
+func (ingredientSectionV2) TableName() string { return "ingredient_sections" }
+
+type userRecipeV3 struct {
+	IdMeal    string `gorm:"primaryKey"`
+	OwnerUUID string `gorm:"index"`
+	Meal      string `gorm:"type:json"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (userRecipeV3) TableName() string { return "user_recipes" }
+
+type mealRatingV4 struct {
+	UserUUID  string `gorm:"primaryKey"`
+	IdMeal    string `gorm:"primaryKey"`
+	Favourite bool
+	Rating    int
+	Blocked   bool
+	UpdatedAt time.Time
+}
+
+func (mealRatingV4) TableName() string { return "meal_ratings" }
+
+type cachedMealV4 struct {
+	IdMeal    string `gorm:"primaryKey"`
+	Meal      string `gorm:"type:json"`
+	UpdatedAt time.Time
+}
+
+func (cachedMealV4) TableName() string { return "cached_meals" }
+
+type dietProfileV5 struct {
+	UserUUID            string `gorm:"primaryKey"`
+	Diets               string `gorm:"type:json"`
+	ExcludedIngredients string `gorm:"type:json"`
+}
+
+func (dietProfileV5) TableName() string { return "diet_profiles" }
+
+type pantryV6 struct {
+	UserUUID    string `gorm:"primaryKey"`
+	Ingredients string `gorm:"type:json"`
+}
+
+func (pantryV6) TableName() string { return "pantries" }
+
+type ingredientPriceV7 struct {
+	Ingredient string `gorm:"primaryKey"`
+	Unit       string `gorm:"primaryKey"`
+	Per        float64
+	Price      float64
+}
+
+func (ingredientPriceV7) TableName() string { return "ingredient_prices" }
+
+type tagV8 struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"uniqueIndex"`
+}
+
+func (tagV8) TableName() string { return "tags" }
+
+type mealTagV8 struct {
+	IdMeal string `gorm:"primaryKey"`
+	TagID  uint   `gorm:"primaryKey"`
+	Tag    tagV8
+}
+
+func (mealTagV8) TableName() string { return "meal_tags" }
+
+type recipesEntryV9 struct {
+	EntryUUID string `gorm:"primaryKey"`
+	UserUUID  string `gorm:"index"`
+	Meals     string `gorm:"type:json"`
+	Schedule  string `gorm:"type:json"`
+	WeekStart time.Time
+	Source    string
+	CreatedAt time.Time
+}
+
+func (recipesEntryV9) TableName() string { return "recipes_entries" }
+
+type planTemplateV10 struct {
+	TemplateUUID string `gorm:"primaryKey"`
+	OwnerUUID    string `gorm:"index"`
+	Name         string
+	Meals        string `gorm:"type:json"`
+	Schedule     string `gorm:"type:json"`
+	CreatedAt    time.Time
+}
+
+func (planTemplateV10) TableName() string { return "plan_templates" }
+
+type recurringRuleV10 struct {
+	UserUUID     string `gorm:"primaryKey"`
+	Weekday      int
+	Minute       int
+	Options      string
+	TemplateUUID *string
+	LastRun      time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (recurringRuleV10) TableName() string { return "recurring_rules" }
+
+type recipesEntryV11 struct {
+	EntryUUID   string `gorm:"primaryKey"`
+	Seed        *int64
+	Options     string
+	Fingerprint string
+}
+
+func (recipesEntryV11) TableName() string { return "recipes_entries" }
+
+type mealV12 struct {
+	IdMeal                   string `gorm:"primaryKey"`
+	OwnerUUID                *string
+	Name                     string
+	Alternate                string
+	Category                 string
+	Area                     string
+	Instructions             string
+	Thumb                    string
+	Tags                     string
+	Youtube                  string
+	Source                   string
+	ImageSource              string
+	CreativeCommonsConfirmed string
+	DateModified             string
+	UpdatedAt                time.Time
+}
+
+func (mealV12) TableName() string { return "meals" }
+
+// normalizedTablesV12 creates the tables of mealV12, ingredientV12, planV12 and planItemV12 with their foreign keys
+var normalizedTablesV12 = []string{
+	`CREATE TABLE meals (id_meal TEXT PRIMARY KEY, owner_uuid TEXT, name TEXT, alternate TEXT, category TEXT,
+		area TEXT, instructions TEXT, thumb TEXT, tags TEXT, youtube TEXT, source TEXT, image_source TEXT,
+		creative_commons_confirmed TEXT, date_modified TEXT, updated_at TIMESTAMP)`,
+	`CREATE INDEX idx_meals_owner_uuid ON meals (owner_uuid)`,
+	`CREATE TABLE ingredients (id_meal TEXT NOT NULL REFERENCES meals (id_meal) ON DELETE CASCADE,
+		position INTEGER NOT NULL, name TEXT, measure TEXT, PRIMARY KEY (id_meal, position))`,
+	`CREATE INDEX idx_ingredients_name ON ingredients (name)`,
+	`CREATE TABLE plans (plan_uuid TEXT PRIMARY KEY, user_uuid TEXT, schedule JSON, week_start TIMESTAMP,
+		source TEXT, seed BIGINT, options TEXT, fingerprint TEXT, created_at TIMESTAMP)`,
+	`CREATE INDEX idx_plans_user_uuid ON plans (user_uuid)`,
+	`CREATE TABLE plan_items (plan_uuid TEXT NOT NULL REFERENCES plans (plan_uuid) ON DELETE CASCADE,
+		position INTEGER NOT NULL, id_meal TEXT NOT NULL REFERENCES meals (id_meal), PRIMARY KEY (plan_uuid, position))`,
+	`CREATE INDEX idx_plan_items_id_meal ON plan_items (id_meal)`,
+}
+
+type ingredientV12 struct {
+	IdMeal   string `gorm:"primaryKey"`
+	Position int    `gorm:"primaryKey;autoIncrement:false"`
+	Name     string `gorm:"index"`
+	Measure  string
+}
+
+func (ingredientV12) TableName() string { return "ingredients" }
+
+type planV12 struct {
+	PlanUUID    string `gorm:"primaryKey"`
+	UserUUID    *string
+	Schedule    []byte `gorm:"type:json"`
+	WeekStart   time.Time
+	Source      string
+	Seed        *int64
+	Options     string
+	Fingerprint string
+	CreatedAt   time.Time
+}
+
+func (planV12) TableName() string { return "plans" }
+
+type planItemV12 struct {
+	PlanUUID string `gorm:"primaryKey"`
+	Position int    `gorm:"primaryKey;autoIncrement:false"`
+	IdMeal   string
+}
+
+func (planItemV12) TableName() string { return "plan_items" }
+
+// cachedMealV12 is cached_meals as splitPlans reads and joinPlans restores it, with the meal as raw JSON
+type cachedMealV12 struct {
+	IdMeal    string `gorm:"primaryKey"`
+	Meal      []byte `gorm:"type:json"`
+	UpdatedAt time.Time
+}
+
+func (cachedMealV12) TableName() string { return "cached_meals" }
+
+// recipesEntryV11Full is recipes_entries with all columns up to version 11, to restore it when splitting is reverted
+type recipesEntryV11Full struct {
+	EntryUUID   string  `gorm:"primaryKey"`
+	UserUUID    *string `gorm:"index"`
+	Meals       []byte  `gorm:"type:json"`
+	Schedule    []byte  `gorm:"type:json"`
+	WeekStart   time.Time
+	Source      string
+	Seed        *int64
+	Options     string
+	Fingerprint string
+	CreatedAt   time.Time
+}
+
+func (recipesEntryV11Full) TableName() string { return "recipes_entries" }
+
+// templateItemsV13 creates the table of templateItemV13 with its foreign keys
+var templateItemsV13 = []string{
+	`CREATE TABLE template_items (template_uuid TEXT NOT NULL REFERENCES plan_templates (template_uuid) ON DELETE CASCADE,
+		position INTEGER NOT NULL, id_meal TEXT NOT NULL REFERENCES meals (id_meal), PRIMARY KEY (template_uuid, position))`,
+	`CREATE INDEX idx_template_items_id_meal ON template_items (id_meal)`,
+}
+
+type templateItemV13 struct {
+	TemplateUUID string `gorm:"primaryKey"`
+	Position     int    `gorm:"primaryKey;autoIncrement:false"`
+	IdMeal       string
+}
+
+func (templateItemV13) TableName() string { return "template_items" }
+
+type storeLayoutV14 struct {
+	UserUUID   string `gorm:"primaryKey"`
+	Store      string `gorm:"primaryKey"`
+	AisleOrder string `gorm:"type:json"`
+}
+
+func (storeLayoutV14) TableName() string { return "store_layouts" }
+
+type ingredientSectionV14 struct {
+	UserUUID   string `gorm:"primaryKey"`
+	Ingredient string `gorm:"primaryKey"`
+	Section    string
+}
+
+func (ingredientSectionV14) TableName() string { return "ingredient_sections" }
+
+type planV15 struct {
+	PlanUUID    string `gorm:"primaryKey"`
+	Candidates  string `gorm:"type:json"`
+	Discoveries string `gorm:"type:json"`
+}
+
+func (planV15) TableName() string { return "plans" }
+
+type userRecipeV16 struct {
+	IdMeal    string `gorm:"primaryKey"`
+	OwnerUUID string `gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (userRecipeV16) TableName() string { return "user_recipes" }
+
+// migrations in the order they are applied, versions count up from 1 without gaps
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create recipes_entries",
+		Up:      func(tx *gorm.DB) error { return createTables(tx, &recipesEntryV1{}) },
+		Down:    func(tx *gorm.DB) error { return dropTables(tx, &recipesEntryV1{}) },
+	},
+	{
+		Version: 2,
+		Name:    "create store layouts and ingredient sections",
+		Up:      func(tx *gorm.DB) error { return createTables(tx, &storeLayoutV2{}, &ingredientSectionV2{}) },
+		Down:    func(tx *gorm.DB) error { return dropTables(tx, &storeLayoutV2{}, &ingredientSectionV2{}) },
+	},
+	{
+		Version: 3,
+		Name:    "create user_recipes",
+		Up:      func(tx *gorm.DB) error { return createTables(tx, &userRecipeV3{}) },
+		Down:    func(tx *gorm.DB) error { return dropTables(tx, &userRecipeV3{}) },
+	},
+	{
+		Version: 4,
+		Name:    "create meal_ratings and cached_meals",
+		Up:      func(tx *gorm.DB) error { return createTables(tx, &mealRatingV4{}, &cachedMealV4{}) },
+		Down:    func(tx *gorm.DB) error { return dropTables(tx, &mealRatingV4{}, &cachedMealV4{}) },
+	},
+	{
+		Version: 5,
+		Name:    "create diet_profiles",
+		Up:      func(tx *gorm.DB) error { return createTables(tx, &dietProfileV5{}) },
+		Down:    func(tx *gorm.DB) error { return dropTables(tx, &dietProfileV5{}) },
+	},
+	{
+		Version: 6,
+		Name:    "create pantries",
+		Up:      func(tx *gorm.DB) error { return createTables(tx, &pantryV6{}) },
+		Down:    func(tx *gorm.DB) error { return dropTables(tx, &pantryV6{}) },
+	},
+	{
+		Version: 7,
+		Name:    "create ingredient_prices",
+		Up:      func(tx *gorm.DB) error { return createTables(tx, &ingredientPriceV7{}) },
+		Down:    func(tx *gorm.DB) error { return dropTables(tx, &ingredientPriceV7{}) },
+	},
+	{
+		Version: 8,
+		Name:    "create tags and meal_tags",
+		Up:      func(tx *gorm.DB) error { return createTables(tx, &tagV8{}, &mealTagV8{}) },
+		Down:    func(tx *gorm.DB) error { return dropTables(tx, &mealTagV8{}, &tagV8{}) },
+	},
+	{
+		Version: 9,
+		Name:    "add owner, schedule, week and source to recipes_entries",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &recipesEntryV9{}, "UserUUID", "Schedule", "WeekStart", "Source", "CreatedAt"); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasIndex(&recipesEntryV9{}, "UserUUID") {
+				if err := tx.Migrator().CreateIndex(&recipesEntryV9{}, "UserUUID"); err != nil {
+					return err
+				}
+			}
+			// plans from before sources were kept were all generated on request
+			return tx.Model(&recipesEntryV9{}).
+				Where("source IS NULL OR source = ''").
+				Update("source", SourceGenerated).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex(&recipesEntryV9{}, "UserUUID") {
+				if err := tx.Migrator().DropIndex(&recipesEntryV9{}, "UserUUID"); err != nil {
+					return err
+				}
+			}
+			return dropColumns(tx, &recipesEntryV9{}, "UserUUID", "Schedule", "WeekStart", "Source", "CreatedAt")
+		},
+	},
+	{
+		Version: 10,
+		Name:    "create plan_templates and recurring_rules",
+		Up:      func(tx *gorm.DB) error { return createTables(tx, &planTemplateV10{}, &recurringRuleV10{}) },
+		Down:    func(tx *gorm.DB) error { return dropTables(tx, &planTemplateV10{}, &recurringRuleV10{}) },
+	},
+	{
+		Version: 11,
+		Name:    "add seed, options and fingerprint to recipes_entries",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &recipesEntryV11{}, "Seed", "Options", "Fingerprint")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &recipesEntryV11{}, "Seed", "Options", "Fingerprint")
+		},
+	},
+	{
+		Version: 12,
+		Name:    "split plans and meals into plans, plan_items, meals and ingredients",
+		Up:      splitPlans,
+		Down:    joinPlans,
+	},
+	{
+		Version: 13,
+		Name:    "split the meals of plan_templates into template_items",
+		Up:      splitTemplates,
+		Down:    joinTemplates,
+	},
+	{
+		Version: 14,
+		Name:    "key store_layouts and ingredient_sections by user",
+		Up:      addSectionOwners,
+		Down:    dropSectionOwners,
+	},
+	{
+		Version: 15,
+		Name:    "add candidates and discoveries to plans",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &planV15{}, "Candidates", "Discoveries")
+		},
+		// SQLite copies a table to drop a column with the migrator, which deletes the plan items by cascade
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"candidates", "discoveries"} {
+				if err := tx.Exec("ALTER TABLE plans DROP COLUMN " + column).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 16,
+		Name:    "store own recipes in meals and ingredients",
+		Up:      moveOwnRecipes,
+		Down:    restoreOwnRecipes,
+	},
+}
+
+// splitPlans moves the meals of the cache and of every plan into the meals and ingredients tables,
+// the plans reference them by plan items
+func splitPlans(tx *gorm.DB) error {
+	for _, statement := range normalizedTablesV12 {
+		if err := tx.Exec(dialectSQL(tx, statement)).Error; err != nil {
+			return err
+		}
+	}
+
+	var cached []cachedMealV12
+	if err := tx.Find(&cached).Error; err != nil {
+		return err
+	}
+	for _, entry := range cached {
+		var meal models.Meal
+		if err := json.Unmarshal(entry.Meal, &meal); err != nil {
+			return err
+		}
+		if err := insertMealV12(tx, meal, nil); err != nil {
+			return err
+		}
+	}
+
+	var recipes []userRecipeV3
+	if err := tx.Find(&recipes).Error; err != nil {
+		return err
+	}
+	owners := make(map[string]string, len(recipes))
+	for _, recipe := range recipes {
+		owners[recipe.IdMeal] = recipe.OwnerUUID
+	}
+
+	var entries []recipesEntryV11Full
+	if err := tx.Find(&entries).Error; err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		var meals []models.Meal
+		if len(entry.Meals) > 0 {
+			if err := json.Unmarshal(entry.Meals, &meals); err != nil {
+				return err
+			}
+		}
+		plan := planV12{
+			PlanUUID:    entry.EntryUUID,
+			UserUUID:    entry.UserUUID,
+			Schedule:    entry.Schedule,
+			WeekStart:   entry.WeekStart,
+			Source:      entry.Source,
+			Seed:        entry.Seed,
+			Options:     entry.Options,
+			Fingerprint: entry.Fingerprint,
+			CreatedAt:   entry.CreatedAt,
+		}
+		if err := tx.Create(&plan).Error; err != nil {
+			return err
+		}
+		for i, meal := range meals {
+			// own recipes keep their owner, even if they were deleted or planned before users were known
+			var owner *string
+			if IsUserRecipeID(meal.IdMeal) {
+				owner = entry.UserUUID
+				if recipeOwner, ok := owners[meal.IdMeal]; ok {
+					owner = &recipeOwner
+				}
+				if owner == nil {
+					unknown := uuid.Nil.String()
+					owner = &unknown
+				}
+			}
+			if err := insertMealV12(tx, meal, owner); err != nil {
+				return err
+			}
+			item := planItemV12{PlanUUID: plan.PlanUUID, Position: i + 1, IdMeal: meal.IdMeal}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return dropTables(tx, &recipesEntryV11Full{}, &cachedMealV12{})
+}
+
+// insertMealV12 adds a meal with its ingredients unless a meal with its id is stored already
+func insertMealV12(tx *gorm.DB, meal models.Meal, owner *string) error {
+	var count int64
+	if err := tx.Model(&mealV12{}).Where("id_meal = ?", meal.IdMeal).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+	row := toMealV12(meal, owner)
+	if err := tx.Create(&row).Error; err != nil {
+		return err
+	}
+	return insertIngredientsV12(tx, meal)
+}
+
+func toMealV12(meal models.Meal, owner *string) mealV12 {
+	return mealV12{
+		IdMeal:                   meal.IdMeal,
+		OwnerUUID:                owner,
+		Name:                     meal.StrMeal,
+		Alternate:                meal.StrMealAlternate,
+		Category:                 meal.StrCategory,
+		Area:                     meal.StrArea,
+		Instructions:             meal.StrInstructions,
+		Thumb:                    meal.StrMealThumb,
+		Tags:                     meal.StrTags,
+		Youtube:                  meal.StrYoutube,
+		Source:                   meal.StrSource,
+		ImageSource:              meal.StrImageSource,
+		CreativeCommonsConfirmed: meal.StrCreativeCommonsConfirmed,
+		DateModified:             meal.DateModified,
+	}
+}
+
+func insertIngredientsV12(tx *gorm.DB, meal models.Meal) error {
+	for i, ingredient := range meal.Ingredients() {
+		item := ingredientV12{IdMeal: meal.IdMeal, Position: i + 1, Name: ingredient.Name, Measure: ingredient.Measure}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinPlans restores recipes_entries and cached_meals with the meals as JSON
+func joinPlans(tx *gorm.DB) error {
+	if err := createTables(tx, &recipesEntryV11Full{}, &cachedMealV12{}); err != nil {
+		return err
+	}
+	var meals []mealV12
+	if err := tx.Find(&meals).Error; err != nil {
+		return err
+	}
+	byID := make(map[string]models.Meal, len(meals))
+	for _, row := range meals {
+		meal, err := loadMealV12(tx, row)
+		if err != nil {
+			return err
+		}
+		byID[row.IdMeal] = meal
+		if row.OwnerUUID == nil {
+			data, err := json.Marshal(meal)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&cachedMealV12{IdMeal: meal.IdMeal, Meal: data}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	var plans []planV12
+	if err := tx.Find(&plans).Error; err != nil {
+		return err
+	}
+	for _, plan := range plans {
+		var items []planItemV12
+		if err := tx.Where("plan_uuid = ?", plan.PlanUUID).Order("position").Find(&items).Error; err != nil {
+			return err
+		}
+		planned := []models.Meal{}
+		for _, item := range items {
+			planned = append(planned, byID[item.IdMeal])
+		}
+		data, err := json.Marshal(planned)
+		if err != nil {
+			return err
+		}
+		entry := recipesEntryV11Full{
+			EntryUUID:   plan.PlanUUID,
+			UserUUID:    plan.UserUUID,
+			Meals:       data,
+			Schedule:    plan.Schedule,
+			WeekStart:   plan.WeekStart,
+			Source:      plan.Source,
+			Seed:        plan.Seed,
+			Options:     plan.Options,
+			Fingerprint: plan.Fingerprint,
+			CreatedAt:   plan.CreatedAt,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return dropTables(tx, &planItemV12{}, &planV12{}, &ingredientV12{}, &mealV12{})
+}
+
+// loadMealV12 returns the meal of a row of the meals table with its ingredients
+func loadMealV12(tx *gorm.DB, row mealV12) (models.Meal, error) {
+	var ingredients []ingredientV12
+	if err := tx.Where("id_meal = ?", row.IdMeal).Order("position").Find(&ingredients).Error; err != nil {
+		return models.Meal{}, err
+	}
+	meal := models.Meal{
+		IdMeal:                      row.IdMeal,
+		StrMeal:                     row.Name,
+		StrMealAlternate:            row.Alternate,
+		StrCategory:                 row.Category,
+		StrArea:                     row.Area,
+		StrInstructions:             row.Instructions,
+		StrMealThumb:                row.Thumb,
+		StrTags:                     row.Tags,
+		StrYoutube:                  row.Youtube,
+		StrSource:                   row.Source,
+		StrImageSource:              row.ImageSource,
+		StrCreativeCommonsConfirmed: row.CreativeCommonsConfirmed,
+		DateModified:                row.DateModified,
+	}
+	var list []models.Ingredient
+	for _, ingredient := range ingredients {
+		list = append(list, models.Ingredient{Name: ingredient.Name, Measure: ingredient.Measure})
+	}
+	meal.SetIngredients(list)
+	return meal, nil
+}
+
+// splitTemplates moves the meals of every template into the meals table, the templates reference them by
+// template items like plans do
+func splitTemplates(tx *gorm.DB) error {
+	var templates []planTemplateV10
+	if err := tx.Find(&templates).Error; err != nil {
+		return err
+	}
+	// dropped before the items reference the templates, SQLite copies the table to drop a column and loses its index
+	if err := dropColumns(tx, &planTemplateV10{}, "Meals"); err != nil {
+		return err
+	}
+	if !tx.Migrator().HasIndex(&planTemplateV10{}, "OwnerUUID") {
+		if err := tx.Migrator().CreateIndex(&planTemplateV10{}, "OwnerUUID"); err != nil {
+			return err
+		}
+	}
+	for _, statement := range templateItemsV13 {
+		if err := tx.Exec(dialectSQL(tx, statement)).Error; err != nil {
+			return err
+		}
+	}
+	for _, template := range templates {
+		var meals []models.Meal
+		if template.Meals != "" {
+			if err := json.Unmarshal([]byte(template.Meals), &meals); err != nil {
+				return err
+			}
+		}
+		for i, meal := range meals {
+			var owner *string
+			if IsUserRecipeID(meal.IdMeal) {
+				owner = &template.OwnerUUID
+			}
+			if err := insertMealV12(tx, meal, owner); err != nil {
+				return err
+			}
+			item := templateItemV13{TemplateUUID: template.TemplateUUID, Position: i + 1, IdMeal: meal.IdMeal}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// joinTemplates restores the meals of the templates as JSON, the meals stay in the meals table
+func joinTemplates(tx *gorm.DB) error {
+	if err := addColumns(tx, &planTemplateV10{}, "Meals"); err != nil {
+		return err
+	}
+	var templates []planTemplateV10
+	if err := tx.Find(&templates).Error; err != nil {
+		return err
+	}
+	for _, template := range templates {
+		var items []templateItemV13
+		if err := tx.Where("template_uuid = ?", template.TemplateUUID).Order("position").Find(&items).Error; err != nil {
+			return err
+		}
+		meals := []models.Meal{}
+		for _, item := range items {
+			var row mealV12
+			if err := tx.First(&row, "id_meal = ?", item.IdMeal).Error; err != nil {
+				return err
+			}
+			meal, err := loadMealV12(tx, row)
+			if err != nil {
+				return err
+			}
+			meals = append(meals, meal)
+		}
+		data, err := json.Marshal(meals)
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&planTemplateV10{}).
+			Where("template_uuid = ?", template.TemplateUUID).
+			Update("meals", string(data)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return dropTables(tx, &templateItemV13{})
+}
+
+// addSectionOwners recreates store_layouts and ingredient_sections with the user as part of their key. The rows from
+// before were shared by everyone, they are kept under the nil user, which no visitor has, so a rollback can restore them.
+func addSectionOwners(tx *gorm.DB) error {
+	var layouts []storeLayoutV2
+	if err := tx.Find(&layouts).Error; err != nil {
+		return err
+	}
+	var sections []ingredientSectionV2
+	if err := tx.Find(&sections).Error; err != nil {
+		return err
+	}
+	if err := dropTables(tx, &storeLayoutV2{}, &ingredientSectionV2{}); err != nil {
+		return err
+	}
+	if err := createTables(tx, &storeLayoutV14{}, &ingredientSectionV14{}); err != nil {
+		return err
+	}
+	shared := uuid.Nil.String()
+	for _, layout := range layouts {
+		row := storeLayoutV14{UserUUID: shared, Store: layout.Store, AisleOrder: layout.AisleOrder}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+	}
+	for _, section := range sections {
+		row := ingredientSectionV14{UserUUID: shared, Ingredient: section.Ingredient, Section: section.Section}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropSectionOwners restores the shared store_layouts and ingredient_sections, the rows of users are lost
+func dropSectionOwners(tx *gorm.DB) error {
+	shared := uuid.Nil.String()
+	var layouts []storeLayoutV14
+	if err := tx.Where("user_uuid = ?", shared).Find(&layouts).Error; err != nil {
+		return err
+	}
+	var sections []ingredientSectionV14
+	if err := tx.Where("user_uuid = ?", shared).Find(&sections).Error; err != nil {
+		return err
+	}
+	if err := dropTables(tx, &storeLayoutV14{}, &ingredientSectionV14{}); err != nil {
+		return err
+	}
+	if err := createTables(tx, &storeLayoutV2{}, &ingredientSectionV2{}); err != nil {
+		return err
+	}
+	for _, layout := range layouts {
+		if err := tx.Create(&storeLayoutV2{Store: layout.Store, AisleOrder: layout.AisleOrder}).Error; err != nil {
+			return err
+		}
+	}
+	for _, section := range sections {
+		row := ingredientSectionV2{Ingredient: section.Ingredient, Section: section.Section}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveOwnRecipes stores every own recipe in the meals and ingredients tables with its owner, user_recipes keeps
+// only which recipes belong to whom. A recipe that was planned has a copy in meals already, the recipe replaces it.
+func moveOwnRecipes(tx *gorm.DB) error {
+	var recipes []userRecipeV3
+	if err := tx.Find(&recipes).Error; err != nil {
+		return err
+	}
+	for _, recipe := range recipes {
+		var meal models.Meal
+		if err := json.Unmarshal([]byte(recipe.Meal), &meal); err != nil {
+			return err
+		}
+		meal.IdMeal = recipe.IdMeal
+		owner := recipe.OwnerUUID
+		row := toMealV12(meal, &owner)
+		result := tx.Model(&mealV12{}).Where("id_meal = ?", meal.IdMeal).Select("*").Updates(&row)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("id_meal = ?", meal.IdMeal).Delete(&ingredientV12{}).Error; err != nil {
+			return err
+		}
+		if err := insertIngredientsV12(tx, meal); err != nil {
+			return err
+		}
+	}
+	// SQLite copies a table to drop a column with the migrator, which loses the owner index
+	return tx.Exec("ALTER TABLE user_recipes DROP COLUMN meal").Error
+}
+
+// restoreOwnRecipes copies the own recipes back into the meal column of user_recipes, the meals stay in the meals table
+func restoreOwnRecipes(tx *gorm.DB) error {
+	if err := addColumns(tx, &userRecipeV3{}, "Meal"); err != nil {
+		return err
+	}
+	var recipes []userRecipeV16
+	if err := tx.Find(&recipes).Error; err != nil {
+		return err
+	}
+	for _, recipe := range recipes {
+		var row mealV12
+		if err := tx.First(&row, "id_meal = ?", recipe.IdMeal).Error; err != nil {
+			return err
+		}
+		meal, err := loadMealV12(tx, row)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(meal)
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&userRecipeV3{}).Where("id_meal = ?", recipe.IdMeal).UpdateColumn("meal", string(data)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !sqlite_fts5
+
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitSearchWithoutFTS5(t *testing.T) {
+	repo := openTestRepository(t, filepath.Join(t.TempDir(), "recipes.db"), nil)
+	if err := repo.InitSearch(context.Background()); !errors.Is(err, ErrNoFTS5) {
+		t.Errorf("InitSearch without FTS5 returned %v, want %v", err, ErrNoFTS5)
+	}
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// Pantry holds the ingredients a user has at home
+type Pantry struct {
+	UserUUID    uuid.UUID   `gorm:"primaryKey"`
+	Ingredients StringsJSON `gorm:"type:json"`
+}
+
+// GetPantry returns the ingredients in the pantry of a user
+func (r *Repository) GetPantry(ctx context.Context, user uuid.UUID) ([]string, error) {
+	pantry := Pantry{UserUUID: user}
+	if err := r.db.WithContext(ctx).Where("user_uuid = ?", user).Limit(1).Find(&pantry).Error; err != nil {
+		return nil, err
+	}
+	return pantry.Ingredients, nil
+}
+
+// SavePantry replaces the ingredients in the pantry of a user
+func (r *Repository) SavePantry(ctx context.Context, user uuid.UUID, ingredients []string) error {
+	pantry := Pantry{
+		UserUUID:    user,
+		Ingredients: ingredients,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&pantry).Error
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"recipeapp/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// planRow is a plan of the plans table, its meals are the plan items
+type planRow struct {
+	PlanUUID    uuid.UUID `gorm:"primaryKey"`
+	UserUUID    *uuid.UUID
+	Schedule    ScheduleJSON `gorm:"type:json"`
+	WeekStart   time.Time
+	Source      string
+	Seed        *int64
+	Options     string
+	Fingerprint string
+	Candidates  StringsJSON `gorm:"type:json"`
+	Discoveries StringsJSON `gorm:"type:json"`
+	CreatedAt   time.Time
+	Items       []planItemRow `gorm:"foreignKey:PlanUUID"`
+}
+
+func (planRow) TableName() string {
+	return "plans"
+}
+
+// planItemRow is the meal at a position of a plan
+type planItemRow struct {
+	PlanUUID uuid.UUID `gorm:"primaryKey"`
+	Position int       `gorm:"primaryKey;autoIncrement:false"`
+	IdMeal   string
+	Meal     mealRow `gorm:"foreignKey:IdMeal"`
+}
+
+func (planItemRow) TableName() string {
+	return "plan_items"
+}
+
+func toPlanRow(entry RecipesEntry) planRow {
+	row := planRow{
+		PlanUUID:    entry.EntryUUID,
+		Schedule:    entry.Schedule,
+		WeekStart:   entry.WeekStart,
+		Source:      entry.Source,
+		Seed:        entry.Seed,
+		Options:     entry.Options,
+		Fingerprint: entry.Fingerprint,
+		Candidates:  entry.Candidates,
+		Discoveries: entry.Discoveries,
+		CreatedAt:   entry.CreatedAt,
+	}
+	if entry.UserUUID != uuid.Nil {
+		row.UserUUID = &entry.UserUUID
+	}
+	return row
+}
+
+func (row planRow) entry() RecipesEntry {
+	entry := RecipesEntry{
+		EntryUUID:   row.PlanUUID,
+		Meals:       MealsJSON{},
+		Schedule:    row.Schedule,
+		WeekStart:   row.WeekStart,
+		Source:      row.Source,
+		Seed:        row.Seed,
+		Options:     row.Options,
+		Fingerprint: row.Fingerprint,
+		Candidates:  row.Candidates,
+		Discoveries: row.Discoveries,
+		CreatedAt:   row.CreatedAt,
+	}
+	if row.UserUUID != nil {
+		entry.UserUUID = *row.UserUUID
+	}
+	for _, item := range row.Items {
+		entry.Meals = append(entry.Meals, item.Meal.meal())
+	}
+	return entry
+}
+
+// withMeals loads the items of plan or template rows in their order with the meals and their ingredients
+func withMeals(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Items.Meal").
+		Preload("Items.Meal.Ingredients", func(db *gorm.DB) *gorm.DB { return db.Order("position") })
+}
+
+// savePlanItems replaces the meals of a plan, meals that are not stored yet are added
+func savePlanItems(tx *gorm.DB, plan uuid.UUID, owner uuid.UUID, meals []models.Meal) error {
+	if err := saveMeals(tx, meals, owner, false); err != nil {
+		return err
+	}
+	if err := tx.Where("plan_uuid = ?", plan).Delete(&planItemRow{}).Error; err != nil {
+		return err
+	}
+	items := make([]planItemRow, 0, len(meals))
+	for i, meal := range meals {
+		items = append(items, planItemRow{PlanUUID: plan, Position: i + 1, IdMeal: meal.IdMeal})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Omit("Meal").Create(&items).Error
+}
+
+// CreateEntry creates a new plan and returns its UUID
+func (r *Repository) CreateEntry(ctx context.Context, entry RecipesEntry) (uuid.UUID, error) {
+	entry.EntryUUID = uuid.New()
+	if entry.Source == "" {
+		entry.Source = SourceGenerated
+	}
+	row := toPlanRow(entry)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Create(&row).Error; err != nil {
+			return err
+		}
+		return savePlanItems(tx, row.PlanUUID, entry.UserUUID, entry.Meals)
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return entry.EntryUUID, nil
+}
+
+// GetUserEntries returns the plans of a user, newest first
+func (r *Repository) GetUserEntries(ctx context.Context, user uuid.UUID) ([]RecipesEntry, error) {
+	var rows []planRow
+	if err := withMeals(r.db.WithContext(ctx)).Where("user_uuid = ?", user).Order("created_at DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]RecipesEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.entry())
+	}
+	return entries, nil
+}
+
+// GetRecipesFromDBByUUID retrieves a plan by UUID and returns its meals
+func (r *Repository) GetRecipesFromDBByUUID(ctx context.Context, id uuid.UUID) ([]models.Meal, error) {
+	entry, err := r.GetEntryByUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Meals, nil
+}
+
+// GetEntryByUUID retrieves a whole plan by UUID
+func (r *Repository) GetEntryByUUID(ctx context.Context, id uuid.UUID) (RecipesEntry, error) {
+	var row planRow
+	if err := withMeals(r.db.WithContext(ctx)).First(&row, "plan_uuid = ?", id).Error; err != nil {
+		return RecipesEntry{}, err
+	}
+	return row.entry(), nil
+}
+
+// UpdateEntry saves the changed meals and schedule of a plan
+func (r *Repository) UpdateEntry(ctx context.Context, entry RecipesEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&planRow{}).
+			Where("plan_uuid = ?", entry.EntryUUID).
+			Update("schedule", entry.Schedule).Error
+		if err != nil {
+			return err
+		}
+		return savePlanItems(tx, entry.EntryUUID, entry.UserUUID, entry.Meals)
+	})
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"recipeapp/pricing"
+
+	"gorm.io/gorm/clause"
+)
+
+// IngredientPrice is an entry of the price catalogue
+type IngredientPrice struct {
+	Ingredient string `gorm:"primaryKey"`
+	Unit       string `gorm:"primaryKey"`
+	Per        float64
+	Price      float64
+}
+
+// GetPrices returns the whole price catalogue ordered by ingredient
+func (r *Repository) GetPrices(ctx context.Context) ([]pricing.Price, error) {
+	var entries []IngredientPrice
+	if err := r.db.WithContext(ctx).Order("ingredient, unit").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	prices := make([]pricing.Price, 0, len(entries))
+	for _, entry := range entries {
+		prices = append(prices, pricing.Price{Ingredient: entry.Ingredient, Unit: entry.Unit, Per: entry.Per, Price: entry.Price})
+	}
+	return prices, nil
+}
+
+// SavePrices creates or replaces prices in the catalogue
+func (r *Repository) SavePrices(ctx context.Context, prices []pricing.Price) error {
+	if len(prices) == 0 {
+		return nil
+	}
+	entries := make([]IngredientPrice, 0, len(prices))
+	for _, p := range prices {
+		entries = append(entries, IngredientPrice{Ingredient: p.Ingredient, Unit: p.Unit, Per: p.Per, Price: p.Price})
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&entries).Error
+}
+
+// DeletePrices removes the prices of an ingredient, only the one of the given unit if it is not empty
+func (r *Repository) DeletePrices(ctx context.Context, ingredient string, unit string) error {
+	query := r.db.WithContext(ctx).Where("ingredient = ?", ingredient)
+	if unit != "" {
+		query = query.Where("unit = ?", unit)
+	}
+	return query.Delete(&IngredientPrice{}).Error
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// MealRating is what a user thinks of a meal
+type MealRating struct {
+	UserUUID  uuid.UUID `gorm:"primaryKey"`
+	IdMeal    string    `gorm:"primaryKey"`
+	Favourite bool
+	Rating    int  // 1 to 5, 0 if not rated
+	Blocked   bool // never plan this meal again
+	UpdatedAt time.Time
+}
+
+// GetRatings returns all ratings of a user
+func (r *Repository) GetRatings(ctx context.Context, user uuid.UUID) ([]MealRating, error) {
+	var ratings []MealRating
+	if err := r.db.WithContext(ctx).Where("user_uuid = ?", user).Order("updated_at desc").Find(&ratings).Error; err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
+
+// GetRating returns the rating of a meal by a user, an empty rating if there is none
+func (r *Repository) GetRating(ctx context.Context, user uuid.UUID, id string) (MealRating, error) {
+	rating := MealRating{UserUUID: user, IdMeal: id}
+	err := r.db.WithContext(ctx).Where("user_uuid = ? AND id_meal = ?", user, id).Limit(1).Find(&rating).Error
+	return rating, err
+}
+
+// SaveRating creates or replaces the rating of a meal by a user
+func (r *Repository) SaveRating(ctx context.Context, rating MealRating) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&rating).Error
+}
+
+// DeleteRating removes the rating of a meal by a user
+func (r *Repository) DeleteRating(ctx context.Context, user uuid.UUID, id string) error {
+	return r.db.WithContext(ctx).Delete(&MealRating{}, "user_uuid = ? AND id_meal = ?", user, id).Error
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// RecurringRule generates a plan for the following week on a weekday and time, every user has at most one
+type RecurringRule struct {
+	UserUUID     uuid.UUID `gorm:"primaryKey"`
+	Weekday      time.Weekday
+	Minute       int        // minute of the day the plan is generated at
+	Options      string     // url encoded options of /api/newrecipes
+	TemplateUUID *uuid.UUID // apply this template instead of generating a plan
+	LastRun      time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// GetRecurringRules returns the rules of all users
+func (r *Repository) GetRecurringRules(ctx context.Context) ([]RecurringRule, error) {
+	var rules []RecurringRule
+	if err := r.db.WithContext(ctx).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetRecurringRule returns the rule of a user
+func (r *Repository) GetRecurringRule(ctx context.Context, user uuid.UUID) (RecurringRule, error) {
+	var rule RecurringRule
+	if err := r.db.WithContext(ctx).First(&rule, "user_uuid = ?", user).Error; err != nil {
+		return RecurringRule{}, err
+	}
+	return rule, nil
+}
+
+// SaveRecurringRule creates or replaces the rule of a user
+func (r *Repository) SaveRecurringRule(ctx context.Context, rule RecurringRule) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&rule).Error
+}
+
+// DeleteRecurringRule deletes the rule of a user, returns ErrNotFound if the user has none
+func (r *Repository) DeleteRecurringRule(ctx context.Context, user uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&RecurringRule{}, "user_uuid = ?", user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimRecurringRule records run as the last run of the rule of the user if it was last run before slot.
+// It reports whether the rule was claimed, of several servers checking the rules at once only one claims a slot.
+// The change time of the rule stays as it is.
+func (r *Repository) ClaimRecurringRule(ctx context.Context, user uuid.UUID, slot, run time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&RecurringRule{}).
+		Where("user_uuid = ? AND last_run < ?", user, slot).
+		UpdateColumn("last_run", run)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ReleaseRecurringRule gives back a run claimed by ClaimRecurringRule, so the rule is run again at the next check
+func (r *Repository) ReleaseRecurringRule(ctx context.Context, user uuid.UUID, run, lastRun time.Time) error {
+	return r.db.WithContext(ctx).Model(&RecurringRule{}).
+		Where("user_uuid = ? AND last_run = ?", user, run).
+		UpdateColumn("last_run", lastRun).Error
+}
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"recipeapp/models"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// searchTable is the FTS5 index over the cached meals
+const searchTable = "meal_search"
+
+// ErrNoFTS5 is returned by InitSearch on SQLite built without FTS5
+var ErrNoFTS5 = errors.New("SQLite has no FTS5, build with -tags sqlite_fts5")
+
+// SearchQuery describes a search over the cached meals. All set fields must match.
+type SearchQuery struct {
+	Text       string // words in title, ingredients, tags, category or area
+	Category   string
+	Area       string
+	Ingredient string
+	Tag        string
+	Limit      int
+	Offset     int
+}
+
+// IsEmpty reports whether the query has neither text nor filters
+func (q SearchQuery) IsEmpty() bool {
+	return q.Text == "" && q.Category == "" && q.Area == "" && q.Ingredient == "" && q.Tag == ""
+}
+
+// searchDocument holds the searchable texts of a meal
+type searchDocument struct {
+	IdMeal      string
+	Title       string
+	Ingredients string
+	Tags        string
+	Category    string
+	Area        string
+}
+
+func newSearchDocument(meal models.Meal) searchDocument {
+	var ingredients []string
+	for _, ingredient := range meal.Ingredients() {
+		ingredients = append(ingredients, ingredient.Name)
+	}
+	return searchDocument{
+		IdMeal:      meal.IdMeal,
+		Title:       meal.StrMeal,
+		Ingredients: strings.Join(ingredients, ", "),
+		Tags:        strings.ReplaceAll(meal.StrTags, ",", " "),
+		Category:    meal.StrCategory,
+		Area:        meal.StrArea,
+	}
+}
+
+// InitSearch creates the full-text index and fills it with the cached meals.
+// On SQLite it fails with ErrNoFTS5 if the driver was built without FTS5, on other databases search scans the cache.
+func (r *Repository) InitSearch(ctx context.Context) error {
+	db := r.db.WithContext(ctx)
+	if db.Name() != "sqlite" {
+		r.fts = false
+		return nil
+	}
+	err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS " + searchTable +
+		" USING fts5(id_meal UNINDEXED, title, ingredients, tags, category, area, tokenize = 'porter unicode61')").Error
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module") {
+			r.fts = false
+			return ErrNoFTS5
+		}
+		return err
+	}
+	r.fts = true
+
+	meals, err := allCachedMeals(db)
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM " + searchTable).Error; err != nil {
+			return err
+		}
+		return r.indexMeals(tx, meals)
+	})
+}
+
+// indexMeals adds or replaces meals in the full-text index
+func (r *Repository) indexMeals(db *gorm.DB, meals []models.Meal) error {
+	if !r.fts {
+		return nil
+	}
+	for _, meal := range meals {
+		doc := newSearchDocument(meal)
+		if err := db.Exec("DELETE FROM "+searchTable+" WHERE id_meal = ?", doc.IdMeal).Error; err != nil {
+			return err
+		}
+		err := db.Exec("INSERT INTO "+searchTable+" (id_meal, title, ingredients, tags, category, area) VALUES (?, ?, ?, ?, ?, ?)",
+			doc.IdMeal, doc.Title, doc.Ingredients, doc.Tags, doc.Category, doc.Area).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchMeals returns one page of the cached meals matching the query, the best matches first,
+// and the number of all matches
+func (r *Repository) SearchMeals(ctx context.Context, query SearchQuery) ([]models.Meal, int, error) {
+	db := r.db.WithContext(ctx)
+	if query.IsEmpty() {
+		return []models.Meal{}, 0, nil
+	}
+	if r.fts {
+		return searchIndex(db, query)
+	}
+	return searchCache(db, query)
+}
+
+// searchIndex runs the query against the FTS5 index, title matches weigh most
+func searchIndex(db *gorm.DB, query SearchQuery) ([]models.Meal, int, error) {
+	var terms []string
+	for _, word := range searchWords(query.Text) {
+		terms = append(terms, `"`+word+`"*`)
+	}
+	if words := searchWords(query.Ingredient); len(words) > 0 {
+		terms = append(terms, `ingredients : "`+strings.Join(words, " ")+`"`)
+	}
+	if words := searchWords(query.Tag); len(words) > 0 {
+		terms = append(terms, `tags : "`+strings.Join(words, " ")+`"`)
+	}
+
+	var conditions []string
+	var args []interface{}
+	if len(terms) > 0 {
+		conditions = append(conditions, searchTable+" MATCH ?")
+		args = append(args, strings.Join(terms, " AND "))
+	}
+	if query.Category != "" {
+		conditions = append(conditions, "category = ? COLLATE NOCASE")
+		args = append(args, query.Category)
+	}
+	if query.Area != "" {
+		conditions = append(conditions, "area = ? COLLATE NOCASE")
+		args = append(args, query.Area)
+	}
+	if len(conditions) == 0 {
+		// the text had no searchable words
+		return []models.Meal{}, 0, nil
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := db.Raw("SELECT count(*) FROM "+searchTable+where, args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	order := " ORDER BY title"
+	if len(terms) > 0 {
+		order = " ORDER BY bm25(" + searchTable + ", 0, 10, 5, 2, 1, 1), title"
+	}
+	var ids []string
+	err := db.Raw("SELECT id_meal FROM "+searchTable+where+order+" LIMIT ? OFFSET ?",
+		append(args, query.Limit, query.Offset)...).Scan(&ids).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cached, err := cachedMeals(db, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	meals := make([]models.Meal, 0, len(ids))
+	for _, id := range ids {
+		if meal, ok := cached[id]; ok {
+			meals = append(meals, meal)
+		}
+	}
+	return meals, total, nil
+}
+
+// searchCache is the fallback without FTS5, it scores every cached meal by where the words occur
+func searchCache(db *gorm.DB, query SearchQuery) ([]models.Meal, int, error) {
+	all, err := allCachedMeals(db)
+	if err != nil {
+		return nil, 0, err
+	}
+	words := searchWords(query.Text)
+	ingredient := strings.Join(searchWords(query.Ingredient), " ")
+	tag := strings.Join(searchWords(query.Tag), " ")
+
+	type match struct {
+		meal  models.Meal
+		score int
+	}
+	var matches []match
+	for _, meal := range all {
+		doc := newSearchDocument(meal)
+		if query.Category != "" && !strings.EqualFold(doc.Category, query.Category) ||
+			query.Area != "" && !strings.EqualFold(doc.Area, query.Area) ||
+			ingredient != "" && !strings.Contains(strings.ToLower(doc.Ingredients), ingredient) ||
+			tag != "" && !strings.Contains(strings.ToLower(doc.Tags), tag) {
+			continue
+		}
+		score, ok := scoreDocument(doc, words)
+		if ok {
+			matches = append(matches, match{meal: meal, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].meal.StrMeal < matches[j].meal.StrMeal
+	})
+
+	meals := []models.Meal{}
+	for i := query.Offset; i < len(matches) && i < query.Offset+query.Limit; i++ {
+		meals = append(meals, matches[i].meal)
+	}
+	return meals, len(matches), nil
+}
+
+// scoreDocument weighs every word by the field it occurs in, false if a word occurs nowhere
+func scoreDocument(doc searchDocument, words []string) (int, bool) {
+	fields := []struct {
+		text   string
+		weight int
+	}{
+		{doc.Title, 10}, {doc.Ingredients, 5}, {doc.Tags, 2}, {doc.Category, 1}, {doc.Area, 1},
+	}
+	score := 0
+	for _, word := range words {
+		found := false
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field.text), word) {
+				score += field.weight
+				found = true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+// searchWords splits a text into lower case words, dropping everything that is no letter or digit.
+// This also keeps FTS5 query syntax out of the user input.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
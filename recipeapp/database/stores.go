@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+type StringsJSON []string
+
+// StoreLayout holds the aisle order a user defined for a store
+type StoreLayout struct {
+	UserUUID   uuid.UUID   `gorm:"primaryKey"`
+	Store      string      `gorm:"primaryKey"`
+	AisleOrder StringsJSON `gorm:"type:json"`
+}
+
+// IngredientSection overrides the default store section of an ingredient for a user
+type IngredientSection struct {
+	UserUUID   uuid.UUID `gorm:"primaryKey"`
+	Ingredient string    `gorm:"primaryKey"`
+	Section    string
+}
+
+// Value marshals the StringsJSON slice into a JSON byte array for database storage
+func (s StringsJSON) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan unmarshals JSON data from the database back into a StringsJSON slice
+func (s *StringsJSON) Scan(value interface{}) error {
+	bytes, ok := jsonBytes(value)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// GetAisleOrder returns the aisle order the user set for a store, nil if there is none
+func (r *Repository) GetAisleOrder(ctx context.Context, user uuid.UUID, store string) ([]string, error) {
+	var layout StoreLayout
+	err := r.db.WithContext(ctx).First(&layout, "user_uuid = ? AND store = ?", user, store).Error
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return layout.AisleOrder, nil
+}
+
+// SaveAisleOrder creates or replaces the aisle order of a store for the user
+func (r *Repository) SaveAisleOrder(ctx context.Context, user uuid.UUID, store string, aisleOrder []string) error {
+	layout := StoreLayout{
+		UserUUID:   user,
+		Store:      store,
+		AisleOrder: aisleOrder,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&layout).Error
+}
+
+// GetIngredientSections returns the section overrides of the user as ingredient -> section
+func (r *Repository) GetIngredientSections(ctx context.Context, user uuid.UUID) (map[string]string, error) {
+	var entries []IngredientSection
+	if err := r.db.WithContext(ctx).Where("user_uuid = ?", user).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	sections := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		sections[entry.Ingredient] = entry.Section
+	}
+	return sections, nil
+}
+
+// SaveIngredientSection creates or replaces the section override of an ingredient for the user
+func (r *Repository) SaveIngredientSection(ctx context.Context, user uuid.UUID, ingredient string, section string) error {
+	entry := IngredientSection{
+		UserUUID:   user,
+		Ingredient: ingredient,
+		Section:    section,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error
+}
+
+// DeleteIngredientSection removes the section override of an ingredient for the user
+func (r *Repository) DeleteIngredientSection(ctx context.Context, user uuid.UUID, ingredient string) error {
+	return r.db.WithContext(ctx).Delete(&IngredientSection{}, "user_uuid = ? AND ingredient = ?", user, ingredient).Error
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"recipeapp/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tag is a normalized tag of meals, e.g. "pasta"
+type Tag struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"uniqueIndex"`
+}
+
+// MealTag links a cached meal or own recipe to one of its tags
+type MealTag struct {
+	IdMeal string `gorm:"primaryKey"`
+	TagID  uint   `gorm:"primaryKey"`
+	Tag    Tag
+}
+
+// TagCount is a tag with the number of meals that have it
+type TagCount struct {
+	Name  string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// visibleMeals restricts meal tags to cached meals and the own recipes of the user
+const visibleMeals = "(meal_tags.id_meal NOT LIKE '" + UserRecipePrefix + "%' OR " +
+	"meal_tags.id_meal IN (SELECT id_meal FROM user_recipes WHERE owner_uuid = ?))"
+
+// InitTags links all cached meals and own recipes to their tags, for meals stored before tags were kept
+func (r *Repository) InitTags(ctx context.Context) error {
+	db := r.db.WithContext(ctx)
+	meals, err := allCachedMeals(db)
+	if err != nil {
+		return err
+	}
+	var recipes []mealRow
+	if err := withIngredients(db).Where("id_meal IN (SELECT id_meal FROM user_recipes)").Find(&recipes).Error; err != nil {
+		return err
+	}
+	for _, recipe := range recipes {
+		meals = append(meals, recipe.meal())
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return tagMeals(tx, meals)
+	})
+}
+
+// tagMeals replaces the tags of the meals with the ones in their StrTags
+func tagMeals(db *gorm.DB, meals []models.Meal) error {
+	for _, meal := range meals {
+		if err := untagMeal(db, meal.IdMeal); err != nil {
+			return err
+		}
+		for _, name := range meal.Tags() {
+			tag := Tag{Name: name}
+			if err := db.Where(Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+				return err
+			}
+			if err := db.Create(&MealTag{IdMeal: meal.IdMeal, TagID: tag.ID}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// untagMeal removes all tags of a meal
+func untagMeal(db *gorm.DB, id string) error {
+	return db.Where("id_meal = ?", id).Delete(&MealTag{}).Error
+}
+
+// GetTagCounts returns the tags of the cached meals and the users own recipes, most used first
+func (r *Repository) GetTagCounts(ctx context.Context, user uuid.UUID) ([]TagCount, error) {
+	counts := []TagCount{}
+	err := r.db.WithContext(ctx).Model(&MealTag{}).
+		Select("tags.name AS name, count(*) AS count").
+		Joins("JOIN tags ON tags.id = meal_tags.tag_id").
+		Where(visibleMeals, user).
+		Group("tags.name").
+		Order("count DESC, tags.name").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// GetMealIDsByTag returns the ids of the cached meals and own recipes of the user with a tag, ordered by id
+func (r *Repository) GetMealIDsByTag(ctx context.Context, user uuid.UUID, tag string) ([]string, error) {
+	ids := []string{}
+	err := r.db.WithContext(ctx).Model(&MealTag{}).
+		Joins("JOIN tags ON tags.id = meal_tags.tag_id").
+		Where("tags.name = ?", models.NormalizeTag(tag)).
+		Where(visibleMeals, user).
+		Order("meal_tags.id_meal").
+		Pluck("meal_tags.id_meal", &ids).Error
+	return ids, err
+}
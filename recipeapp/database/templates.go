@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PlanTemplate is a plan saved by a user to be applied to other weeks, e.g. "Winter week A",
+// stored in the plan_templates and template_items tables
+type PlanTemplate struct {
+	TemplateUUID uuid.UUID
+	OwnerUUID    uuid.UUID
+	Name         string
+	Meals        MealsJSON
+	Schedule     ScheduleJSON
+	CreatedAt    time.Time
+}
+
+// templateRow is a template of the plan_templates table, its meals are the template items
+type templateRow struct {
+	TemplateUUID uuid.UUID `gorm:"primaryKey"`
+	OwnerUUID    uuid.UUID `gorm:"index"`
+	Name         string
+	Schedule     ScheduleJSON `gorm:"type:json"`
+	CreatedAt    time.Time
+	Items        []templateItemRow `gorm:"foreignKey:TemplateUUID"`
+}
+
+func (templateRow) TableName() string {
+	return "plan_templates"
+}
+
+// templateItemRow is the meal at a position of a template
+type templateItemRow struct {
+	TemplateUUID uuid.UUID `gorm:"primaryKey"`
+	Position     int       `gorm:"primaryKey;autoIncrement:false"`
+	IdMeal       string
+	Meal         mealRow `gorm:"foreignKey:IdMeal"`
+}
+
+func (templateItemRow) TableName() string {
+	return "template_items"
+}
+
+func (row templateRow) template() PlanTemplate {
+	template := PlanTemplate{
+		TemplateUUID: row.TemplateUUID,
+		OwnerUUID:    row.OwnerUUID,
+		Name:         row.Name,
+		Meals:        MealsJSON{},
+		Schedule:     row.Schedule,
+		CreatedAt:    row.CreatedAt,
+	}
+	for _, item := range row.Items {
+		template.Meals = append(template.Meals, item.Meal.meal())
+	}
+	return template
+}
+
+// CreateTemplate stores a new template and returns it with its id, meals that are not stored yet are added
+func (r *Repository) CreateTemplate(ctx context.Context, template PlanTemplate) (PlanTemplate, error) {
+	row := templateRow{
+		TemplateUUID: uuid.New(),
+		OwnerUUID:    template.OwnerUUID,
+		Name:         template.Name,
+		Schedule:     template.Schedule,
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Create(&row).Error; err != nil {
+			return err
+		}
+		if err := saveMeals(tx, template.Meals, template.OwnerUUID, false); err != nil {
+			return err
+		}
+		items := make([]templateItemRow, 0, len(template.Meals))
+		for i, meal := range template.Meals {
+			items = append(items, templateItemRow{TemplateUUID: row.TemplateUUID, Position: i + 1, IdMeal: meal.IdMeal})
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Omit("Meal").Create(&items).Error
+	})
+	if err != nil {
+		return PlanTemplate{}, err
+	}
+	template.TemplateUUID = row.TemplateUUID
+	template.CreatedAt = row.CreatedAt
+	return template, nil
+}
+
+// GetTemplates returns the templates of the owner ordered by name
+func (r *Repository) GetTemplates(ctx context.Context, owner uuid.UUID) ([]PlanTemplate, error) {
+	var rows []templateRow
+	if err := withMeals(r.db.WithContext(ctx)).Where("owner_uuid = ?", owner).Order("name").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	templates := make([]PlanTemplate, 0, len(rows))
+	for _, row := range rows {
+		templates = append(templates, row.template())
+	}
+	return templates, nil
+}
+
+// GetTemplate returns a single template of the owner
+func (r *Repository) GetTemplate(ctx context.Context, owner uuid.UUID, id uuid.UUID) (PlanTemplate, error) {
+	var row templateRow
+	if err := withMeals(r.db.WithContext(ctx)).First(&row, "template_uuid = ? AND owner_uuid = ?", id, owner).Error; err != nil {
+		return PlanTemplate{}, err
+	}
+	return row.template(), nil
+}
+
+// DeleteTemplate deletes a template of the owner with its items, returns ErrNotFound if the owner has no such template
+func (r *Repository) DeleteTemplate(ctx context.Context, owner uuid.UUID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&templateRow{}, "template_uuid = ? AND owner_uuid = ?", id, owner)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"recipeapp/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserRecipePrefix marks the ids of user-owned recipes so they never collide with TheMealDB ids
+const UserRecipePrefix = "u-"
+
+// UserRecipe is a recipe owned by a user, imported or written by them. The meal itself is stored in the meals and
+// ingredients tables with its owner.
+type UserRecipe struct {
+	IdMeal    string    `gorm:"primaryKey"`
+	OwnerUUID uuid.UUID `gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// IsUserRecipeID reports whether a meal id belongs to a user-owned recipe
+func IsUserRecipeID(id string) bool {
+	return len(id) > len(UserRecipePrefix) && id[:len(UserRecipePrefix)] == UserRecipePrefix
+}
+
+// ownRecipes restricts meal rows to the recipes of the owner
+func ownRecipes(db *gorm.DB, owner uuid.UUID) *gorm.DB {
+	return withIngredients(db).
+		Joins("JOIN user_recipes ON user_recipes.id_meal = meals.id_meal").
+		Where("user_recipes.owner_uuid = ?", owner)
+}
+
+// CreateUserRecipe stores a meal as recipe of the owner and returns it with its new id
+func (r *Repository) CreateUserRecipe(ctx context.Context, owner uuid.UUID, meal models.Meal) (models.Meal, error) {
+	meal.IdMeal = UserRecipePrefix + uuid.NewString()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveMeals(tx, []models.Meal{meal}, owner, true); err != nil {
+			return err
+		}
+		if err := tx.Create(&UserRecipe{IdMeal: meal.IdMeal, OwnerUUID: owner}).Error; err != nil {
+			return err
+		}
+		return tagMeals(tx, []models.Meal{meal})
+	})
+	if err != nil {
+		return models.Meal{}, err
+	}
+	return meal, nil
+}
+
+// GetUserRecipes returns all recipes of the owner, oldest first
+func (r *Repository) GetUserRecipes(ctx context.Context, owner uuid.UUID) ([]models.Meal, error) {
+	var rows []mealRow
+	if err := ownRecipes(r.db.WithContext(ctx), owner).Order("user_recipes.created_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	meals := make([]models.Meal, 0, len(rows))
+	for _, row := range rows {
+		meals = append(meals, row.meal())
+	}
+	return meals, nil
+}
+
+// GetUserRecipe returns a single recipe of the owner
+func (r *Repository) GetUserRecipe(ctx context.Context, owner uuid.UUID, id string) (models.Meal, error) {
+	var row mealRow
+	if err := ownRecipes(r.db.WithContext(ctx), owner).First(&row, "meals.id_meal = ?", id).Error; err != nil {
+		return models.Meal{}, err
+	}
+	return row.meal(), nil
+}
+
+// UpdateUserRecipe replaces a recipe of the owner, returns ErrNotFound if the owner has no such recipe. Plans and
+// templates reference the recipe, so they show the new version.
+func (r *Repository) UpdateUserRecipe(ctx context.Context, owner uuid.UUID, meal models.Meal) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&UserRecipe{}).
+			Where("id_meal = ? AND owner_uuid = ?", meal.IdMeal, owner).
+			Update("updated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if err := saveMeals(tx, []models.Meal{meal}, owner, true); err != nil {
+			return err
+		}
+		return tagMeals(tx, []models.Meal{meal})
+	})
+}
+
+// DeleteUserRecipe deletes a recipe of the owner, returns ErrNotFound if the owner has no such recipe. The meal stays
+// for the plans and templates that contain it.
+func (r *Repository) DeleteUserRecipe(ctx context.Context, owner uuid.UUID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&UserRecipe{}, "id_meal = ? AND owner_uuid = ?", id, owner)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		err := tx.Where("id_meal = ?", id).
+			Where("id_meal NOT IN (SELECT id_meal FROM plan_items) AND id_meal NOT IN (SELECT id_meal FROM template_items)").
+			Delete(&mealRow{}).Error
+		if err != nil {
+			return err
+		}
+		return untagMeal(tx, id)
+	})
+}
@@ -0,0 +1,123 @@
+package diet
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"recipeapp/shoppinglist"
+	"strings"
+)
+
+// Class is an allergen or diet relevant property of an ingredient
+type Class string
+
+const (
+	ClassMeat   Class = "meat"
+	ClassFish   Class = "fish"
+	ClassDairy  Class = "dairy"
+	ClassEgg    Class = "egg"
+	ClassGluten Class = "gluten"
+	ClassNut    Class = "nut"
+	ClassAnimal Class = "animal" // other animal products like honey or gelatine
+)
+
+var knownClasses = map[Class]bool{
+	ClassMeat: true, ClassFish: true, ClassDairy: true, ClassEgg: true, ClassGluten: true, ClassNut: true, ClassAnimal: true,
+}
+
+//go:embed ingredients.csv
+var ingredientsCSV string
+
+// ingredientClasses maps the singularized names of known ingredients to their classes
+var ingredientClasses = mustLoadClasses(strings.NewReader(ingredientsCSV))
+
+// Classify returns the classes of an ingredient, none if it is plant based and free of allergens.
+// The name is matched against the known ingredients, names they do not list are split into the longest known parts,
+// so "soft goat cheese" is dairy and "butter beans" does not contain butter.
+func Classify(ingredient string) []Class {
+	words := ingredientWords(ingredient)
+	seen := make(map[Class]bool)
+	var classes []Class
+	for i := 0; i < len(words); {
+		end := i + 1
+		for j := len(words); j > i; j-- {
+			part, exists := ingredientClasses[strings.Join(words[i:j], " ")]
+			if !exists {
+				continue
+			}
+			for _, class := range part {
+				if !seen[class] {
+					seen[class] = true
+					classes = append(classes, class)
+				}
+			}
+			end = j
+			break
+		}
+		i = end
+	}
+	return classes
+}
+
+// ingredientWords returns the singularized words of an ingredient name
+func ingredientWords(name string) []string {
+	words := strings.Fields(shoppinglist.NormalizeIngredient(name))
+	for i, word := range words {
+		words[i] = singular(strings.TrimSuffix(strings.Trim(word, ",()"), "'s"))
+	}
+	return words
+}
+
+// singular returns the singular of an English noun for matching names, not for display.
+// Both forms of a word only have to end up the same, "tomatoes" and "tomato" both become "tomato".
+func singular(word string) string {
+	switch {
+	case len(word) <= 3 || strings.HasSuffix(word, "ss") || strings.HasSuffix(word, "us"):
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes") || strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes") ||
+		strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	default:
+		return strings.TrimSuffix(word, "s")
+	}
+}
+
+// loadClasses reads a table with the columns ingredient and classes, the classes separated by spaces.
+// Lines starting with # are comments.
+func loadClasses(r io.Reader) (map[string][]Class, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(map[string][]Class, len(records))
+	for i, record := range records {
+		if i == 0 {
+			continue // header
+		}
+		classes := []Class{}
+		for _, field := range strings.Fields(record[1]) {
+			class := Class(field)
+			if !knownClasses[class] {
+				return nil, fmt.Errorf("%s: unknown class %q", record[0], field)
+			}
+			classes = append(classes, class)
+		}
+		table[strings.Join(ingredientWords(record[0]), " ")] = classes
+	}
+	return table, nil
+}
+
+func mustLoadClasses(r io.Reader) map[string][]Class {
+	table, err := loadClasses(r)
+	if err != nil {
+		panic("diet: ingredient classes: " + err.Error())
+	}
+	return table
+}
@@ -0,0 +1,117 @@
+package diet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"recipeapp/models"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		ingredient string
+		classes    []Class
+	}{
+		{"Walnut", []Class{ClassNut}},
+		{"Walnuts", []Class{ClassNut}},
+		{"Hazelnut", []Class{ClassNut}},
+		{"Pecan", []Class{ClassNut}},
+		{"Pine Nuts", []Class{ClassNut}},
+		{"Peanut Butter", []Class{ClassNut}},
+		{"Nutmeg", nil},
+		{"Rabbit", []Class{ClassMeat}},
+		{"Goose", []Class{ClassMeat}},
+		{"Pepperoni", []Class{ClassMeat}},
+		{"Meatballs", []Class{ClassMeat}},
+		{"Oxtail", []Class{ClassMeat}},
+		{"Chicken Thigh", []Class{ClassMeat}},
+		{"Monkfish", []Class{ClassFish}},
+		{"Swordfish", []Class{ClassFish}},
+		{"Sea Bass", []Class{ClassFish}},
+		{"Anchovies", []Class{ClassFish}},
+		{"Halloumi", []Class{ClassDairy}},
+		{"Gorgonzola", []Class{ClassDairy}},
+		{"Pecorino", []Class{ClassDairy}},
+		{"Goat Cheese", []Class{ClassDairy}},
+		{"Goat's Cheese", []Class{ClassDairy}},
+		{"soft goats cheese", []Class{ClassDairy}},
+		{"Goat Meat", []Class{ClassMeat}},
+		{"Semolina", []Class{ClassGluten}},
+		{"Digestive Biscuits", []Class{ClassGluten, ClassDairy}},
+		{"Egg Noodles", []Class{ClassEgg, ClassGluten}},
+		{"Rice Noodles", nil},
+		{"Butter Beans", nil},
+		{"Coconut Milk", nil},
+		{"Eggplant", nil},
+		{"Water Chestnut", nil},
+		{"Chestnut Mushroom", nil},
+		{"Cherry Tomatoes", nil},
+		{"Honey", []Class{ClassAnimal}},
+		{"  Smoked   BACON ", []Class{ClassMeat}},
+		{"Tuna, drained", []Class{ClassFish}},
+		{"Star Fruit", nil},
+	}
+	for _, test := range tests {
+		if classes := Classify(test.ingredient); !reflect.DeepEqual(classes, test.classes) {
+			t.Errorf("%q is %v, want %v", test.ingredient, classes, test.classes)
+		}
+	}
+}
+
+func TestSingular(t *testing.T) {
+	tests := []struct {
+		word, singular string
+	}{
+		{"walnuts", "walnut"},
+		{"anchovies", "anchovy"},
+		{"tomatoes", "tomato"},
+		{"peaches", "peach"},
+		{"radishes", "radish"},
+		{"bass", "bass"},
+		{"couscous", "couscous"},
+		{"asparagus", "asparagus"},
+		{"peas", "pea"},
+		{"gas", "gas"},
+		{"feta", "feta"},
+	}
+	for _, test := range tests {
+		if singular := singular(test.word); singular != test.singular {
+			t.Errorf("singular(%q) = %q, want %q", test.word, singular, test.singular)
+		}
+	}
+}
+
+func TestLoadClasses(t *testing.T) {
+	table, err := loadClasses(strings.NewReader("ingredient,classes\n# comment\nEgg Noodles,egg gluten\nRice,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(table["egg noodle"], []Class{ClassEgg, ClassGluten}) || len(table["rice"]) != 0 {
+		t.Errorf("unexpected table %v", table)
+	}
+	if _, err := loadClasses(strings.NewReader("ingredient,classes\nTofu,soy\n")); err == nil {
+		t.Error("expected an error for an unknown class")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	meal := models.Meal{StrMeal: "Walnut Pesto", StrIngredient1: "Walnut", StrIngredient2: "Pecorino", StrIngredient3: "Basil"}
+	tests := []struct {
+		profile Profile
+		reasons int
+	}{
+		{Profile{}, 0},
+		{Profile{Diets: []Diet{Vegetarian}}, 0},
+		{Profile{Diets: []Diet{NutFree}}, 1},
+		{Profile{Diets: []Diet{Vegan}}, 1},
+		{Profile{Diets: []Diet{NutFree, DairyFree}}, 2},
+		{Profile{Excluded: []string{"basil"}}, 1},
+		{Profile{Excluded: []string{"nut"}}, 0},
+	}
+	for _, test := range tests {
+		if reasons := test.profile.Check(meal); len(reasons) != test.reasons {
+			t.Errorf("%+v gives %v, want %d reasons", test.profile, reasons, test.reasons)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package diet
+
+import (
+	"fmt"
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+	"strings"
+)
+
+// Diet is a dietary restriction a profile can have
+type Diet string
+
+const (
+	Vegetarian  Diet = "vegetarian"
+	Vegan       Diet = "vegan"
+	Pescatarian Diet = "pescatarian"
+	GlutenFree  Diet = "gluten-free"
+	DairyFree   Diet = "dairy-free"
+	NutFree     Diet = "nut-free"
+)
+
+// forbidden lists the ingredient classes each diet excludes
+var forbidden = map[Diet][]Class{
+	Vegetarian:  {ClassMeat, ClassFish},
+	Vegan:       {ClassMeat, ClassFish, ClassDairy, ClassEgg, ClassAnimal},
+	Pescatarian: {ClassMeat},
+	GlutenFree:  {ClassGluten},
+	DairyFree:   {ClassDairy},
+	NutFree:     {ClassNut},
+}
+
+// ParseDiet parses a diet name, returning false if it is not a known diet
+func ParseDiet(s string) (Diet, bool) {
+	d := Diet(strings.ToLower(strings.TrimSpace(s)))
+	_, ok := forbidden[d]
+	return d, ok
+}
+
+// Profile holds the dietary restrictions of a user
+type Profile struct {
+	Diets    []Diet   `json:"diets"`
+	Excluded []string `json:"excluded_ingredients"` // ingredients the user never wants, matched by name
+}
+
+// IsEmpty reports whether the profile restricts anything
+func (p Profile) IsEmpty() bool {
+	return len(p.Diets) == 0 && len(p.Excluded) == 0
+}
+
+// Check returns why a meal does not fit the profile, nothing if it does
+func (p Profile) Check(meal models.Meal) []string {
+	var reasons []string
+	for _, ingredient := range meal.Ingredients() {
+		classes := Classify(ingredient.Name)
+		for _, d := range p.Diets {
+			for _, class := range classes {
+				if forbids(d, class) {
+					reasons = append(reasons, fmt.Sprintf("%s (%s) is not %s", ingredient.Name, class, d))
+				}
+			}
+		}
+		if excluded := p.excludes(ingredient.Name); excluded != "" {
+			reasons = append(reasons, fmt.Sprintf("%s matches excluded ingredient %q", ingredient.Name, excluded))
+		}
+	}
+	return reasons
+}
+
+// excludes returns the excluded ingredient that matches the name, empty if none does.
+// An exclusion matches whole words of the name in singular or plural, "nut" does not exclude "nutmeg".
+func (p Profile) excludes(name string) string {
+	name = " " + shoppinglist.NormalizeIngredient(name) + " "
+	for _, excluded := range p.Excluded {
+		normalized := shoppinglist.NormalizeIngredient(excluded)
+		if normalized == "" {
+			continue
+		}
+		if strings.Contains(name, " "+normalized+" ") || strings.Contains(name, " "+normalized+"s ") {
+			return excluded
+		}
+	}
+	return ""
+}
+
+func forbids(d Diet, class Class) bool {
+	for _, c := range forbidden[d] {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
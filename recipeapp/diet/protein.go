@@ -0,0 +1,53 @@
+package diet
+
+import (
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+	"strings"
+)
+
+// proteinKeywords map words of ingredient names to the protein they stand for
+var proteinKeywords = map[string]string{
+	"chicken": "chicken", "turkey": "turkey", "duck": "duck",
+	"beef": "beef", "brisket": "beef", "steak": "beef", "veal": "beef",
+	"pork": "pork", "bacon": "pork", "ham": "pork", "pancetta": "pork", "chorizo": "pork",
+	"prosciutto": "pork", "sausage": "pork", "sausages": "pork",
+	"lamb": "lamb", "mutton": "lamb", "goat": "goat", "venison": "game",
+	"cod": "fish", "fish": "fish", "haddock": "fish", "mackerel": "fish", "salmon": "fish",
+	"sardines": "fish", "trout": "fish", "tuna": "fish",
+	"clams": "seafood", "crab": "seafood", "lobster": "seafood", "mussels": "seafood", "oysters": "seafood",
+	"prawn": "seafood", "prawns": "seafood", "scallops": "seafood", "shrimp": "seafood", "squid": "seafood",
+	"tofu": "tofu", "tempeh": "tofu",
+	"chickpeas": "legumes", "lentils": "legumes", "beans": "legumes",
+	"egg": "egg", "eggs": "egg",
+	"paneer": "cheese", "halloumi": "cheese",
+}
+
+// flavourings name ingredients that only add taste, "chicken stock" is not the protein of a meal
+var flavourings = map[string]bool{
+	"stock": true, "broth": true, "sauce": true, "powder": true, "cube": true, "cubes": true, "fat": true,
+	"noodles": true, "paste": true,
+}
+
+// MainProtein returns the protein of a meal, taken from the first ingredient that is one, empty if there is none.
+// Meals list their main ingredient first, so "chicken thighs" makes a chicken meal even if eggs follow.
+func MainProtein(meal models.Meal) string {
+	for _, ingredient := range meal.Ingredients() {
+		words := strings.Fields(shoppinglist.NormalizeIngredient(ingredient.Name))
+		protein := ""
+		for _, word := range words {
+			word = strings.Trim(word, ",()")
+			if flavourings[word] {
+				protein = ""
+				break
+			}
+			if p, ok := proteinKeywords[word]; ok && protein == "" {
+				protein = p
+			}
+		}
+		if protein != "" {
+			return protein
+		}
+	}
+	return ""
+}
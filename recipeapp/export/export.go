@@ -0,0 +1,126 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+)
+
+// Format is an output format of the shopping list export
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+	FormatHTML     Format = "html"
+)
+
+// ContentTypes maps each format to the content type it is served with
+var ContentTypes = map[Format]string{
+	FormatText:     "text/plain; charset=utf-8",
+	FormatMarkdown: "text/markdown; charset=utf-8",
+	FormatCSV:      "text/csv; charset=utf-8",
+	FormatJSON:     "application/json; charset=utf-8",
+	FormatHTML:     "text/html; charset=utf-8",
+}
+
+// FileExtensions maps each format to the extension of the downloaded file
+var FileExtensions = map[Format]string{
+	FormatText:     "txt",
+	FormatMarkdown: "md",
+	FormatCSV:      "csv",
+	FormatJSON:     "json",
+	FormatHTML:     "html",
+}
+
+// ParseFormat parses a format name, also accepting the file extensions
+func ParseFormat(s string) (Format, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for format, ext := range FileExtensions {
+		if s == string(format) || s == ext {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// ShoppingList renders the shopping list items of a plan in the given format
+func ShoppingList(format Format, meals []models.Meal, items []shoppinglist.ShoppingItem) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return markdown(items), nil
+	case FormatCSV:
+		return csvList(items)
+	case FormatJSON:
+		return json.MarshalIndent(items, "", "  ")
+	case FormatHTML:
+		return html(meals, items)
+	default:
+		return text(items), nil
+	}
+}
+
+// text renders one item per line, with a header line per section
+func text(items []shoppinglist.ShoppingItem) []byte {
+	var buf bytes.Buffer
+	var section shoppinglist.Section
+	for i, item := range items {
+		if item.Section != section {
+			if i > 0 {
+				buf.WriteString("\n")
+			}
+			section = item.Section
+			buf.WriteString(strings.ToUpper(string(section)) + "\n")
+		}
+		buf.WriteString(item.String() + "\n")
+	}
+	return buf.Bytes()
+}
+
+// markdown renders a checklist with a heading per section
+func markdown(items []shoppinglist.ShoppingItem) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# Shopping List\n")
+	var section shoppinglist.Section
+	for _, item := range items {
+		if item.Section != section {
+			section = item.Section
+			buf.WriteString("\n## " + sectionTitle(section) + "\n\n")
+		}
+		buf.WriteString("- [ ] " + item.String() + "\n")
+	}
+	return buf.Bytes()
+}
+
+// csvList renders a CSV table with a header row
+func csvList(items []shoppinglist.ShoppingItem) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"section", "ingredient", "amount", "unit"}); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		record := []string{string(item.Section), item.Ingredient, strconv.FormatFloat(item.Amount, 'f', -1, 64), item.Unit}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// sectionTitle capitalizes a section name for headings
+func sectionTitle(section shoppinglist.Section) string {
+	s := string(section)
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
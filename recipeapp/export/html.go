@@ -0,0 +1,63 @@
+package export
+
+import (
+	"bytes"
+	"html/template"
+
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+)
+
+type htmlSection struct {
+	Title string
+	Items []string
+}
+
+var printTemplate = template.Must(template.New("print").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Shopping List</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+h2 { border-bottom: 1px solid #ccc; margin-top: 1.5em; }
+ul { list-style: none; padding-left: 0; }
+li::before { content: "\2610\00a0\00a0"; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Weekly Plan</h1>
+<ol>
+{{range .Meals}}<li>{{.StrMeal}}{{if .StrCategory}} ({{.StrCategory}}){{end}}</li>
+{{end}}</ol>
+<h1>Shopping List</h1>
+{{range .Sections}}<h2>{{.Title}}</h2>
+<ul>
+{{range .Items}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+// html renders a printable page with the plan and the shopping list
+func html(meals []models.Meal, items []shoppinglist.ShoppingItem) ([]byte, error) {
+	var sections []htmlSection
+	for _, item := range items {
+		if len(sections) == 0 || sections[len(sections)-1].Title != sectionTitle(item.Section) {
+			sections = append(sections, htmlSection{Title: sectionTitle(item.Section)})
+		}
+		last := &sections[len(sections)-1]
+		last.Items = append(last.Items, item.String())
+	}
+
+	var buf bytes.Buffer
+	err := printTemplate.Execute(&buf, struct {
+		Meals    []models.Meal
+		Sections []htmlSection
+	}{meals, sections})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
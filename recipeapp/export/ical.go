@@ -0,0 +1,101 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"recipeapp/models"
+	"recipeapp/planner"
+)
+
+// ICalContentType is the content type the calendar feed is served with
+const ICalContentType = "text/calendar; charset=utf-8"
+
+const (
+	icalDateTime   = "20060102T150405"
+	icalLineLength = 75
+	mealLength     = time.Hour
+)
+
+// ICal renders the plan as an iCalendar feed with one dinner event per day, the first on the start day
+// at the given dinner time. Leftovers days repeat the cooked meal, days eating out have no event.
+func ICal(planID string, days []planner.PlannedDay, start time.Time, dinner time.Duration, now time.Time) []byte {
+	var buf bytes.Buffer
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:-//recipeapp//weekly plan//EN")
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "X-WR-CALNAME:Weekly Plan")
+
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+	for i, planned := range days {
+		if planned.Meal == nil {
+			continue
+		}
+		meal := *planned.Meal
+		summary := "Dinner: " + meal.StrMeal
+		if planned.Kind == planner.Leftovers {
+			summary = "Leftovers: " + meal.StrMeal
+		}
+		begin := day.AddDate(0, 0, i).Add(dinner)
+		writeLine(&buf, "BEGIN:VEVENT")
+		writeLine(&buf, fmt.Sprintf("UID:%s-%d@recipeapp", planID, i))
+		writeLine(&buf, "DTSTAMP:"+now.UTC().Format(icalDateTime)+"Z")
+		writeLine(&buf, "DTSTART:"+begin.Format(icalDateTime))
+		writeLine(&buf, "DTEND:"+begin.Add(mealLength).Format(icalDateTime))
+		writeLine(&buf, "SUMMARY:"+escapeText(summary))
+		if description := eventDescription(meal); description != "" {
+			writeLine(&buf, "DESCRIPTION:"+escapeText(description))
+		}
+		if meal.StrSource != "" {
+			writeLine(&buf, "URL:"+meal.StrSource)
+		}
+		writeLine(&buf, "END:VEVENT")
+	}
+
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// eventDescription lists category, area and ingredients of a meal
+func eventDescription(meal models.Meal) string {
+	var parts []string
+	if meal.StrCategory != "" || meal.StrArea != "" {
+		parts = append(parts, strings.TrimSpace(meal.StrArea+" "+meal.StrCategory))
+	}
+	if ingredients := meal.Ingredients(); len(ingredients) > 0 {
+		names := make([]string, 0, len(ingredients))
+		for _, ingredient := range ingredients {
+			names = append(names, strings.TrimSpace(ingredient.Measure+" "+ingredient.Name))
+		}
+		parts = append(parts, "Ingredients: "+strings.Join(names, ", "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// escapeText escapes a TEXT value as described in RFC 5545 section 3.3.11
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ";", "\\;")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	s = strings.ReplaceAll(s, "\r\n", "\\n")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+// writeLine writes a content line, folded after 75 octets, terminated by CRLF
+func writeLine(buf *bytes.Buffer, line string) {
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > icalLineLength {
+			buf.WriteString("\r\n ")
+			length = 1
+		}
+		buf.WriteRune(r)
+		length += size
+	}
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+)
+
+// vulgarFractions are replaced by plain fractions so the measure parser understands them
+var vulgarFractions = strings.NewReplacer(
+	"½", " 1/2", "⅓", " 1/3", "⅔", " 2/3", "¼", " 1/4", "¾", " 3/4",
+	"⅕", " 1/5", "⅛", " 1/8", "⅜", " 3/8", "⅝", " 5/8", "⅞", " 7/8",
+)
+
+// quantity matches a leading amount like "2", "1 1/2", "3/4", "1.5" or a range like "2-3"
+var quantity = regexp.MustCompile(`^(\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?(?:\s*-\s*\d+(?:[.,]\d+)?)?)\s*`)
+
+// rangeBound is the upper bound of a range amount like "2-3"
+var rangeBound = regexp.MustCompile(`^.*-\s*`)
+
+// fractionDenominators are the denominators decimal amounts are rounded to, as recipes use them
+var fractionDenominators = []int{2, 3, 4, 8}
+
+// extraUnits are common recipe units the shopping list converter does not standardize
+var extraUnits = map[string]bool{
+	"clove": true, "cloves": true, "slice": true, "slices": true, "sprig": true, "sprigs": true,
+	"handful": true, "bunch": true, "large": true, "medium": true, "small": true, "piece": true,
+	"pieces": true, "stick": true, "sticks": true, "head": true, "heads": true, "pinches": true,
+	"grams": true, "kilograms": true, "milliliters": true, "millilitres": true, "ml.": true,
+}
+
+// ParseIngredientLine splits a free text ingredient line like "2 cups flour, sifted" into
+// the measure "2 cups" and the name "flour, sifted"
+func ParseIngredientLine(line string) models.Ingredient {
+	line = strings.Join(strings.Fields(vulgarFractions.Replace(line)), " ")
+
+	m := quantity.FindStringSubmatch(line)
+	if m == nil {
+		return models.Ingredient{Name: line}
+	}
+	measure := normalizeAmount(strings.TrimSpace(m[1]))
+	rest := line[len(m[0]):]
+
+	// A parenthesized size like "1 (400g) can" belongs to the measure
+	if strings.HasPrefix(rest, "(") {
+		if end := strings.Index(rest, ")"); end != -1 {
+			measure += " " + rest[:end+1]
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+
+	words := strings.Fields(rest)
+	if len(words) > 1 {
+		unit := strings.ToLower(strings.TrimRight(words[0], ".,"))
+		if shoppinglist.IsKnownUnit(unit) || extraUnits[unit] {
+			measure += " " + words[0]
+			words = words[1:]
+		}
+	}
+	if len(words) > 1 && strings.EqualFold(words[0], "of") {
+		words = words[1:]
+	}
+
+	return models.Ingredient{
+		Name:    strings.Join(words, " "),
+		Measure: measure,
+	}
+}
+
+// normalizeAmount rewrites amounts the shopping list cannot read: a range becomes its upper bound, so enough is
+// bought, and a decimal like "1.5" or "1,5" the nearest fraction like "1 1/2"
+func normalizeAmount(amount string) string {
+	amount = rangeBound.ReplaceAllString(amount, "")
+	if !strings.ContainsAny(amount, ".,") {
+		return amount
+	}
+	value, err := strconv.ParseFloat(strings.Replace(amount, ",", ".", 1), 64)
+	if err != nil {
+		return amount
+	}
+	return fraction(value)
+}
+
+// fraction writes a positive value as whole number, fraction or mixed number, rounded to the nearest
+// half, third, quarter or eighth
+func fraction(value float64) string {
+	whole := math.Floor(value)
+	rest := value - whole
+	numerator, denominator := 0, 1
+	best := rest
+	for _, d := range fractionDenominators {
+		n := int(math.Round(rest * float64(d)))
+		if diff := math.Abs(rest - float64(n)/float64(d)); diff < best-1e-9 {
+			numerator, denominator, best = n, d, diff
+		}
+	}
+	if numerator == denominator {
+		whole, numerator = whole+1, 0
+	}
+	switch {
+	case numerator == 0 && whole == 0:
+		return "1/8" // less than a sixteenth, still an amount to buy
+	case numerator == 0:
+		return strconv.Itoa(int(whole))
+	case whole == 0:
+		return fmt.Sprintf("%d/%d", numerator, denominator)
+	default:
+		return fmt.Sprintf("%d %d/%d", int(whole), numerator, denominator)
+	}
+}
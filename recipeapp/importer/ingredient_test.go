@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"testing"
+
+	"recipeapp/shoppinglist"
+)
+
+func TestParseIngredientLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		measure string
+		name    string
+	}{
+		{"2 cups flour, sifted", "2 cups", "flour, sifted"},
+		{"1 ½ cups milk", "1 1/2 cups", "milk"},
+		{"3/4 tsp salt", "3/4 tsp", "salt"},
+		{"1 (400g) can chopped tomatoes", "1 (400g) can", "chopped tomatoes"},
+		{"2 cloves of garlic", "2 cloves", "garlic"},
+		{"Salt and pepper", "", "Salt and pepper"},
+		// decimals become fractions and ranges their upper bound, the shopping list reads neither
+		{"1.5 cups flour", "1 1/2 cups", "flour"},
+		{"1,5 kg potatoes", "1 1/2 kg", "potatoes"},
+		{"0.25 cup sugar", "1/4 cup", "sugar"},
+		{"0.33 cup oil", "1/3 cup", "oil"},
+		{"2.0 l water", "2 l", "water"},
+		{"2-3 cloves garlic", "3 cloves", "garlic"},
+		{"1.5 - 2 kg beef", "2 kg", "beef"},
+	}
+	for _, test := range tests {
+		ingredient := ParseIngredientLine(test.line)
+		if ingredient.Measure != test.measure || ingredient.Name != test.name {
+			t.Errorf("%q: measure %q, name %q, want %q, %q", test.line, ingredient.Measure, ingredient.Name,
+				test.measure, test.name)
+		}
+		if err := shoppinglist.ValidateMeasure(ingredient.Measure); err != nil {
+			t.Errorf("%q: %v", test.line, err)
+		}
+	}
+}
+
+func TestFromJSONLDUnparsedMeasures(t *testing.T) {
+	result, err := FromJSONLD([]byte(`{"@type": "Recipe", "name": "Omelette",
+		"recipeIngredient": ["2.5 eggs", "0 g butter"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingredients := result.Meal.Ingredients()
+	if len(ingredients) != 2 || ingredients[0].Measure != "2 1/2" || ingredients[1].Measure != "" {
+		t.Errorf("ingredients %+v", ingredients)
+	}
+	if len(result.UnparsedMeasures) != 1 || result.UnparsedMeasures[0] != "0 g butter" {
+		t.Errorf("unparsed measures %v", result.UnparsedMeasures)
+	}
+}
@@ -0,0 +1,215 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+)
+
+var ErrNoRecipe = errors.New("no schema.org Recipe found")
+
+// ldScript matches the JSON-LD script blocks of an HTML document
+var ldScript = regexp.MustCompile(`(?is)<script[^>]+type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+
+// htmlTag matches markup that some sites leave inside JSON-LD text values
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// Result is an imported meal together with what could not be mapped
+type Result struct {
+	Meal               models.Meal `json:"meal"`
+	SkippedIngredients []string    `json:"skipped_ingredients,omitempty"`
+	UnparsedMeasures   []string    `json:"unparsed_measures,omitempty"` // lines imported without their measure
+}
+
+// FromHTML extracts the first schema.org Recipe from the JSON-LD blocks of an HTML document
+func FromHTML(page []byte) (Result, error) {
+	for _, m := range ldScript.FindAllSubmatch(page, -1) {
+		result, err := FromJSONLD(m[1])
+		if err == nil {
+			return result, nil
+		}
+	}
+	return Result{}, ErrNoRecipe
+}
+
+// FromJSONLD maps a schema.org Recipe JSON-LD document into a meal.
+// The document may be a single node, an array of nodes or a graph.
+func FromJSONLD(data []byte) (Result, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Result{}, err
+	}
+	node := findRecipe(doc)
+	if node == nil {
+		return Result{}, ErrNoRecipe
+	}
+	return mapRecipe(node), nil
+}
+
+// findRecipe searches a JSON-LD document for the first node with the type Recipe
+func findRecipe(doc interface{}) map[string]interface{} {
+	switch v := doc.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if node := findRecipe(item); node != nil {
+				return node
+			}
+		}
+	case map[string]interface{}:
+		for _, t := range texts(v["@type"]) {
+			if t == "Recipe" || strings.HasSuffix(t, "/Recipe") {
+				return v
+			}
+		}
+		if node := findRecipe(v["@graph"]); node != nil {
+			return node
+		}
+		if node := findRecipe(v["mainEntity"]); node != nil {
+			return node
+		}
+	}
+	return nil
+}
+
+// mapRecipe maps the fields of a Recipe node into a meal
+func mapRecipe(node map[string]interface{}) Result {
+	meal := models.Meal{
+		StrMeal:         first(texts(node["name"])),
+		StrCategory:     first(texts(node["recipeCategory"])),
+		StrArea:         first(texts(node["recipeCuisine"])),
+		StrInstructions: strings.Join(instructions(node["recipeInstructions"]), "\r\n"),
+		StrMealThumb:    first(images(node["image"])),
+		StrTags:         strings.Join(keywords(node["keywords"]), ","),
+		StrSource:       first(texts(node["url"])),
+		DateModified:    first(texts(node["dateModified"])),
+	}
+
+	var ingredients []models.Ingredient
+	var skipped, unparsed []string
+	for _, line := range texts(node["recipeIngredient"]) {
+		ingredient := ParseIngredientLine(line)
+		if ingredient.Name == "" {
+			continue
+		}
+		if len(ingredients) == models.MaxIngredients {
+			skipped = append(skipped, line)
+			continue
+		}
+		// the shopping list has to read the measure, like for recipes entered by hand
+		if err := shoppinglist.ValidateMeasure(ingredient.Measure); err != nil {
+			unparsed = append(unparsed, line)
+			ingredient.Measure = ""
+		}
+		ingredients = append(ingredients, ingredient)
+	}
+	meal.SetIngredients(ingredients)
+
+	return Result{Meal: meal, SkippedIngredients: skipped, UnparsedMeasures: unparsed}
+}
+
+// instructions flattens text, HowToStep and HowToSection instructions into single steps
+func instructions(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		var steps []string
+		for _, line := range strings.Split(cleanText(v, true), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				steps = append(steps, line)
+			}
+		}
+		return steps
+	case []interface{}:
+		var steps []string
+		for _, item := range v {
+			steps = append(steps, instructions(item)...)
+		}
+		return steps
+	case map[string]interface{}:
+		if elements, ok := v["itemListElement"]; ok {
+			return instructions(elements)
+		}
+		if text := first(texts(v["text"])); text != "" {
+			return instructions(text)
+		}
+		return instructions(v["name"])
+	}
+	return nil
+}
+
+// images returns the URLs of an image given as URL, ImageObject or list of both
+func images(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var urls []string
+		for _, item := range v {
+			urls = append(urls, images(item)...)
+		}
+		return urls
+	case map[string]interface{}:
+		return texts(v["url"])
+	}
+	return nil
+}
+
+// keywords splits comma separated keywords into single tags
+func keywords(v interface{}) []string {
+	var tags []string
+	for _, text := range texts(v) {
+		for _, tag := range strings.Split(text, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// texts returns a JSON-LD value that can be a single string or a list of strings as list
+func texts(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		if text := cleanText(v, false); text != "" {
+			return []string{text}
+		}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, texts(item)...)
+		}
+		return values
+	case map[string]interface{}:
+		if id, ok := v["@id"].(string); ok {
+			return []string{id}
+		}
+	}
+	return nil
+}
+
+// cleanText removes markup and entities from a text value, keepLines keeps its line breaks
+func cleanText(s string, keepLines bool) string {
+	s = htmlTag.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	if keepLines {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			lines[i] = strings.Join(strings.Fields(line), " ")
+		}
+		return strings.TrimSpace(strings.Join(lines, "\n"))
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
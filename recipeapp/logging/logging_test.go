@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "verbose", FormatText); err == nil {
+		t.Error("unknown level accepted")
+	}
+	if _, err := New(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("unknown format accepted")
+	}
+	var out bytes.Buffer
+	logger, err := New(&out, "WARN", FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if bytes.Contains(out.Bytes(), []byte("hidden")) || !bytes.Contains(out.Bytes(), []byte("shown")) {
+		t.Errorf("log %q, want only the warning", out.String())
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&out, nil)))
+
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{"generated", "", false},
+		{"from proxy", "abc-123.x_y", true},
+		{"unsafe", "abc\" injected=\"1", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out.Reset()
+			var seen string
+			router := gin.New()
+			router.Use(Middleware())
+			router.GET("/plans/:id", func(c *gin.Context) {
+				seen = RequestID(c.Request.Context())
+			})
+			req := httptest.NewRequest("GET", "/plans/0b7e5c1e-2f4a-4f7e-9d4c-8f3a2b1c0d9e", nil)
+			req.Header.Set(RequestIDHeader, test.incoming)
+			req.AddCookie(&http.Cookie{Name: "recipe_cookie", Value: "secret-token"})
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			if id == "" || id != seen || (id == test.incoming) != test.kept {
+				t.Errorf("request id %q, handler saw %q, incoming %q", id, seen, test.incoming)
+			}
+			var entry map[string]interface{}
+			if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+				t.Fatalf("log %q: %v", out.String(), err)
+			}
+			if entry["request_id"] != id || entry["route"] != "/plans/:id" || entry["status"] != float64(200) {
+				t.Errorf("log entry %v", entry)
+			}
+			if bytes.Contains(out.Bytes(), []byte("secret-token")) {
+				t.Errorf("cookie logged: %s", out.String())
+			}
+			if bytes.Contains(out.Bytes(), []byte("0b7e5c1e")) {
+				t.Errorf("plan id of the path logged: %s", out.String())
+			}
+		})
+	}
+}
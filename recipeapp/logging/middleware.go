@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request id, taken from the request if a proxy set it and returned in the response
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID accepts ids of proxies that cannot smuggle anything into the logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Middleware gives every request an id, stores it in the context of the request and logs the request
+// when it is done. Only method, route, status, duration and client are logged, never headers or cookies,
+// and the route instead of the path since paths like /api/history/:id carry plan ids, which are also cookie tokens.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		FromContext(c.Request.Context()).Log(c.Request.Context(), level, "request",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"status", c.Writer.Status(),
+			"duration", time.Since(start),
+			"client", c.ClientIP(),
+		)
+	}
+}
@@ -1,9 +1,21 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
 	"recipeapp/api"
+	"recipeapp/client"
 	"recipeapp/database"
+	"recipeapp/logging"
+	"recipeapp/metrics"
+	"recipeapp/nutrition"
+	"recipeapp/scheduler"
+	"recipeapp/serverError"
+	"recipeapp/shoppinglist"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/contrib/static"
@@ -13,37 +25,212 @@ import (
 
 const port = ":8080"
 
-var db *gorm.DB
+const nutritionFile = "nutrition.csv"
+
+// schedulerInterval is how often the recurring plan rules are checked
+const schedulerInterval = time.Minute
+
+var (
+	migrateDryRun = flag.Bool("migrate-dry-run", false, "list the pending schema migrations and exit")
+	migrateTo     = flag.Int("migrate-to", -1, "migrate the schema up or down to this version and exit")
+
+	databaseDSN     = flag.String("database", envOr("RECIPEAPP_DATABASE", database.DefaultDSN), "SQLite file or postgres:// URL, also set by RECIPEAPP_DATABASE")
+	maxOpenConns    = flag.Int("db-max-open-conns", 0, "maximum open database connections, 0 for no limit")
+	maxIdleConns    = flag.Int("db-max-idle-conns", 0, "maximum idle database connections, 0 for the default of 2")
+	connMaxLifetime = flag.Duration("db-conn-max-lifetime", 0, "close database connections after this time, 0 to keep them")
+
+	logLevel  = flag.String("log-level", envOr("RECIPEAPP_LOG_LEVEL", "info"), "debug, info, warn or error, also set by RECIPEAPP_LOG_LEVEL")
+	logFormat = flag.String("log-format", envOr("RECIPEAPP_LOG_FORMAT", logging.FormatText), "text or json, also set by RECIPEAPP_LOG_FORMAT")
+)
 
 func main() {
-	db = initDB()
+	flag.Parse()
+	logger, err := logging.New(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	if *migrateDryRun || *migrateTo >= 0 {
+		runMigrations()
+		return
+	}
+	handlers := &api.Handlers{
+		Plans:         initDB(),
+		Recipes:       client.Client{},
+		ShoppingLists: shoppinglist.Builder{},
+		Nutrition:     loadNutrition(),
+	}
+
+	recurring := scheduler.Scheduler{Interval: schedulerInterval, Check: handlers.RunRecurringRules}
+	go recurring.Run(context.Background())
 
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:8080"} // restrict to local frontend
 	config.AllowCredentials = true
 	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Set-Cookie"}
 
-	router := gin.Default()
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(logging.Middleware()) // Give every request an id and log it
+	router.Use(metrics.Middleware()) // Count the requests and their duration by route
 	router.Use(cors.New(config))
+	router.Use(serverError.Middleware())                                        // Answer the errors handlers report with c.Error
 	router.Use(static.Serve("/", static.LocalFile("./ui/recipeapp/out", true))) // Serving the frontend
 
+	router.GET("/metrics", gin.WrapH(metrics.Handler())) // Prometheus metrics
+
 	apiGroup := router.Group("/api") // API group for all API routes
 
-	apiGroup.GET("/recipes", api.GetRecipes)    // Get a list of saved Recipes from the database by the users cookies
-	apiGroup.GET("/newrecipes", api.NewRecipes) // Get a list of new Recipes from the database by the users cookies
+	apiGroup.GET("/recipes", handlers.GetRecipes)        // Get a list of saved Recipes from the database by the users cookies
+	apiGroup.GET("/newrecipes", handlers.NewRecipes)     // Get a list of new Recipes from the database by the users cookies
+	apiGroup.PUT("/plan/days/:day", handlers.PutPlanDay) // Cook, eat leftovers or eat out on a day of the plan
+
+	apiGroup.GET("/templates", handlers.ListTemplates)               // List the users plan templates
+	apiGroup.POST("/templates", handlers.CreateTemplate)             // Save the current plan as template
+	apiGroup.DELETE("/templates/:id", handlers.DeleteTemplate)       // Delete a plan template
+	apiGroup.POST("/templates/:id/apply", handlers.ApplyTemplate)    // Create a plan for a week from a template
+	apiGroup.GET("/recurring", handlers.GetRecurring)                // Get the users recurring plan rule
+	apiGroup.PUT("/recurring", handlers.PutRecurring)                // Generate a plan for next week every week
+	apiGroup.DELETE("/recurring", handlers.DeleteRecurring)          // Stop the recurring plans
+	apiGroup.GET("/history", handlers.ListHistory)                   // List the users plans
+	apiGroup.GET("/history/:id", handlers.GetHistoryPlan)            // Get a plan of the history
+	apiGroup.POST("/history/:id/select", handlers.SelectHistoryPlan) // Make a plan of the history the current plan
 
-	router.Run(port) // listen and serve on
+	apiGroup.GET("/debug/plans/:id/regenerate", handlers.RegeneratePlan) // Generate a plan again from its stored seed
+
+	apiGroup.GET("/search", handlers.Search)         // Search meals by name, ingredient, tag, category or area
+	apiGroup.GET("/tags", handlers.ListTags)         // List the tags with their number of meals
+	apiGroup.GET("/tags/:tag", handlers.GetTagMeals) // Browse the meals with a tag
+
+	apiGroup.POST("/import", handlers.ImportRecipe)            // Import a schema.org Recipe as own recipe
+	apiGroup.GET("/myrecipes", handlers.ListMyRecipes)         // List the users own recipes
+	apiGroup.POST("/myrecipes", handlers.CreateMyRecipe)       // Create an own recipe
+	apiGroup.GET("/myrecipes/:id", handlers.GetMyRecipe)       // Get an own recipe
+	apiGroup.PUT("/myrecipes/:id", handlers.UpdateMyRecipe)    // Edit an own recipe
+	apiGroup.DELETE("/myrecipes/:id", handlers.DeleteMyRecipe) // Delete an own recipe
+
+	apiGroup.GET("/ratings", handlers.ListRatings)         // List the users favourites, ratings and blocked meals
+	apiGroup.PUT("/ratings/:id", handlers.PutRating)       // Star, rate or block a meal
+	apiGroup.DELETE("/ratings/:id", handlers.DeleteRating) // Forget the rating of a meal
+
+	apiGroup.GET("/profile/diet", handlers.GetDietProfile) // Get the users dietary restrictions
+	apiGroup.PUT("/profile/diet", handlers.PutDietProfile) // Set the users dietary restrictions
+
+	apiGroup.GET("/pantry", handlers.GetPantry)             // Get the ingredients the user has at home
+	apiGroup.PUT("/pantry", handlers.PutPantry)             // Set the ingredients the user has at home
+	apiGroup.POST("/cookwhatihave", handlers.CookWhatIHave) // Propose meals for the ingredients at hand
+
+	apiGroup.GET("/cook", handlers.GetCookingPlan)     // Get the meals of the users plan as steps with timers
+	apiGroup.GET("/cook/:id", handlers.GetCookingMeal) // Get a single meal as steps with timers
+
+	apiGroup.GET("/nutrition", handlers.GetNutrition) // Estimate the nutrients of the users plan
+
+	apiGroup.GET("/prices", handlers.ListPrices)                 // List the price catalogue
+	apiGroup.PUT("/prices/:ingredient", handlers.PutPrice)       // Set the price of an ingredient
+	apiGroup.DELETE("/prices/:ingredient", handlers.DeletePrice) // Remove the prices of an ingredient
+	apiGroup.POST("/prices/import", handlers.ImportPrices)       // Import prices from CSV
+
+	apiGroup.GET("/export", handlers.ExportShoppingList)                // Export the shopping list as text, markdown, csv, json or html
+	apiGroup.GET("/plan.ics", handlers.ExportCalendar)                  // Export the plan as an iCalendar file
+	apiGroup.GET("/plan/:id/calendar.ics", handlers.ExportPlanCalendar) // iCalendar feed of a plan to subscribe to
+
+	apiGroup.GET("/stores/:store", handlers.GetStore)                          // Get the aisle order of a store
+	apiGroup.PUT("/stores/:store", handlers.PutStore)                          // Set the aisle order of a store
+	apiGroup.GET("/sections", handlers.GetSections)                            // Get the store sections and ingredient overrides
+	apiGroup.PUT("/sections/:ingredient", handlers.PutIngredientSection)       // Move an ingredient into another section
+	apiGroup.DELETE("/sections/:ingredient", handlers.DeleteIngredientSection) // Reset an ingredient to its default section
+
+	slog.Info("listening", "address", port)
+	if err := router.Run(port); err != nil {
+		fatal("server stopped", err)
+	}
 }
 
-func initDB() *gorm.DB {
-	dbNew, err := database.ConnectToSQLite()
+// initDB migrates the database of the flags and prepares its search index and tags
+func initDB() *database.Repository {
+	dbNew, err := openDB()
 	if err != nil {
-		log.Fatal(err)
+		fatal("opening the database failed", err)
 	}
-	err = dbNew.AutoMigrate(&database.RecipesEntry{})
+	steps, err := database.Migrate(dbNew, false)
+	for _, step := range steps {
+		slog.Info("applied schema migration", "migration", step)
+	}
+	if err != nil {
+		fatal("migrating the database failed", err)
+	}
+	repo := database.NewRepository(dbNew)
+	if err := repo.InitSearch(context.Background()); err != nil {
+		fatal("preparing the search index failed", err)
+	}
+	if err := repo.InitTags(context.Background()); err != nil {
+		fatal("preparing the tags failed", err)
+	}
+	return repo
+}
+
+// runMigrations migrates the schema as asked by the flags without starting the server
+func runMigrations() {
+	dbNew, err := openDB()
+	if err != nil {
+		fatal("opening the database failed", err)
+	}
+	current, err := database.CurrentVersion(dbNew)
+	if err != nil {
+		fatal("reading the schema version failed", err)
+	}
+	target := database.LatestVersion()
+	if *migrateTo >= 0 {
+		target = *migrateTo
+	}
+	steps, err := database.MigrateTo(dbNew, target, *migrateDryRun)
+	slog.Info("migrating schema", "version", current, "migrations", len(steps), "target", target)
+	for _, step := range steps {
+		if *migrateDryRun {
+			slog.Info("pending schema migration", "migration", step)
+		} else {
+			slog.Info("applied schema migration", "migration", step)
+		}
+	}
+	if err != nil {
+		fatal("migrating the database failed", err)
+	}
+}
+
+// openDB connects to the database of the flags
+func openDB() (*gorm.DB, error) {
+	slog.Info("using database", "backend", database.BackendFor(*databaseDSN).Name())
+	return database.Open(database.Config{
+		DSN:             *databaseDSN,
+		MaxOpenConns:    *maxOpenConns,
+		MaxIdleConns:    *maxIdleConns,
+		ConnMaxLifetime: *connMaxLifetime,
+	})
+}
+
+// envOr returns the environment variable or the fallback if it is not set
+func envOr(name string, fallback string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return fallback
+}
+
+// loadNutrition reads the nutrition table, an empty one if there is none
+func loadNutrition() nutrition.Table {
+	table, err := nutrition.LoadFile(nutritionFile)
 	if err != nil {
-		log.Fatal(err)
+		// Nutrition estimates are optional, without a table every ingredient is reported as missing
+		slog.Warn("no nutrition data loaded", "error", err)
+		return nutrition.Table{}
 	}
-	database.SetDB(dbNew)
-	return dbNew
+	return table
+}
+
+// fatal logs why the server cannot run and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
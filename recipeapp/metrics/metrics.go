@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds the metrics of the server, served by Handler
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts the requests by method, route and status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "recipeapp_http_requests_total",
+		Help: "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration observes how long requests take by method and route
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "recipeapp_http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// MealDBRequests counts the calls to TheMealDB by endpoint and result, "ok" or "error"
+	MealDBRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "recipeapp_themealdb_requests_total",
+		Help: "Calls to TheMealDB by endpoint and result.",
+	}, []string{"endpoint", "result"})
+
+	// MealDBDuration observes how long calls to TheMealDB take by endpoint, failed ones included
+	MealDBDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "recipeapp_themealdb_request_duration_seconds",
+		Help:    "Duration of calls to TheMealDB by endpoint.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	// CategoryRejections counts the meals left out of new plans because their category is not planned as dinner
+	CategoryRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "recipeapp_plan_category_rejections_total",
+		Help: "Meals left out of new plans for their category, by category.",
+	}, []string{"category"})
+
+	// DBQueryDuration observes how long database queries take by operation and table
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "recipeapp_db_query_duration_seconds",
+		Help:    "Duration of database queries by operation and table.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 9), // 0.1ms to 6.5s
+	}, []string{"operation", "table"})
+
+	// MealCacheLookups counts the meals looked up in the meal cache by result, "hit" or "miss"
+	MealCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "recipeapp_meal_cache_lookups_total",
+		Help: "Meals looked up in the meal cache by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration,
+		MealDBRequests, MealDBDuration,
+		CategoryRejections,
+		DBQueryDuration,
+		MealCacheLookups,
+	)
+}
+
+// Handler serves the metrics of Registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
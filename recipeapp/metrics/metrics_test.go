@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/api/tags/:tag", func(c *gin.Context) { c.Status(200) })
+	router.GET("/metrics", gin.WrapH(Handler()))
+
+	for _, path := range []string{"/api/tags/quick", "/api/tags/vegan", "/ui/some/file.js"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if n := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/api/tags/:tag", "200")); n != 2 {
+		t.Errorf("%v requests counted for the route, want 2", n)
+	}
+	if n := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", unmatchedRoute, "404")); n != 1 {
+		t.Errorf("%v unmatched requests counted, want 1", n)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`recipeapp_http_requests_total{method="GET",route="/api/tags/:tag",status="200"} 2`,
+		`recipeapp_http_request_duration_seconds_count{method="GET",route="/api/tags/:tag"} 2`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics have no %s", want)
+		}
+	}
+	if strings.Contains(body, "file.js") {
+		t.Error("path of an unmatched request used as label")
+	}
+}
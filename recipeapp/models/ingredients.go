@@ -0,0 +1,65 @@
+package models
+
+import "strings"
+
+// MaxIngredients is the number of ingredient slots a meal has
+const MaxIngredients = 20
+
+// Ingredient is a single ingredient of a meal with its measure
+type Ingredient struct {
+	Name    string `json:"name"`
+	Measure string `json:"measure"`
+}
+
+// Ingredients returns the non-empty ingredients of the meal with their measures
+func (m Meal) Ingredients() []Ingredient {
+	names := [MaxIngredients]string{
+		m.StrIngredient1, m.StrIngredient2, m.StrIngredient3, m.StrIngredient4, m.StrIngredient5,
+		m.StrIngredient6, m.StrIngredient7, m.StrIngredient8, m.StrIngredient9, m.StrIngredient10,
+		m.StrIngredient11, m.StrIngredient12, m.StrIngredient13, m.StrIngredient14, m.StrIngredient15,
+		m.StrIngredient16, m.StrIngredient17, m.StrIngredient18, m.StrIngredient19, m.StrIngredient20,
+	}
+	measures := [MaxIngredients]string{
+		m.StrMeasure1, m.StrMeasure2, m.StrMeasure3, m.StrMeasure4, m.StrMeasure5,
+		m.StrMeasure6, m.StrMeasure7, m.StrMeasure8, m.StrMeasure9, m.StrMeasure10,
+		m.StrMeasure11, m.StrMeasure12, m.StrMeasure13, m.StrMeasure14, m.StrMeasure15,
+		m.StrMeasure16, m.StrMeasure17, m.StrMeasure18, m.StrMeasure19, m.StrMeasure20,
+	}
+
+	var ingredients []Ingredient
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		ingredients = append(ingredients, Ingredient{
+			Name:    name,
+			Measure: strings.TrimSpace(measures[i]),
+		})
+	}
+	return ingredients
+}
+
+// SetIngredients replaces the ingredients of the meal, only the first 20 are kept
+func (m *Meal) SetIngredients(ingredients []Ingredient) {
+	names := [MaxIngredients]*string{
+		&m.StrIngredient1, &m.StrIngredient2, &m.StrIngredient3, &m.StrIngredient4, &m.StrIngredient5,
+		&m.StrIngredient6, &m.StrIngredient7, &m.StrIngredient8, &m.StrIngredient9, &m.StrIngredient10,
+		&m.StrIngredient11, &m.StrIngredient12, &m.StrIngredient13, &m.StrIngredient14, &m.StrIngredient15,
+		&m.StrIngredient16, &m.StrIngredient17, &m.StrIngredient18, &m.StrIngredient19, &m.StrIngredient20,
+	}
+	measures := [MaxIngredients]*string{
+		&m.StrMeasure1, &m.StrMeasure2, &m.StrMeasure3, &m.StrMeasure4, &m.StrMeasure5,
+		&m.StrMeasure6, &m.StrMeasure7, &m.StrMeasure8, &m.StrMeasure9, &m.StrMeasure10,
+		&m.StrMeasure11, &m.StrMeasure12, &m.StrMeasure13, &m.StrMeasure14, &m.StrMeasure15,
+		&m.StrMeasure16, &m.StrMeasure17, &m.StrMeasure18, &m.StrMeasure19, &m.StrMeasure20,
+	}
+
+	for i := range names {
+		*names[i], *measures[i] = "", ""
+		if i < len(ingredients) {
+			*names[i] = ingredients[i].Name
+			*measures[i] = ingredients[i].Measure
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "strings"
+
+// Tags returns the normalized tags of the meal: lower case, trimmed and without duplicates
+func (m Meal) Tags() []string {
+	return ParseTags(m.StrTags)
+}
+
+// ParseTags splits a comma-separated tag list like "Pasta,Baked" into normalized tags
+func ParseTags(tags string) []string {
+	var parsed []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(tags, ",") {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		parsed = append(parsed, tag)
+	}
+	return parsed
+}
+
+// NormalizeTag makes tags comparable, "StirFry " and "stirfry" are the same tag
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
@@ -0,0 +1,194 @@
+package nutrition
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+	"strconv"
+	"strings"
+)
+
+// Values are the nutrients of an amount of food
+type Values struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein_g"`
+	Fat      float64 `json:"fat_g"`
+	Carbs    float64 `json:"carbs_g"`
+}
+
+// entry holds the nutrients of a reference amount of an ingredient in one unit
+type entry struct {
+	per    float64
+	values Values
+}
+
+// Table maps a normalized ingredient name and a standard unit to its nutrients
+type Table map[string]map[string]entry
+
+// Coverage tells which ingredients had nutrition data
+type Coverage struct {
+	Covered int      `json:"covered"`
+	Total   int      `json:"total"`
+	Missing []string `json:"missing"`
+}
+
+// MealNutrition is the estimate for a single meal
+type MealNutrition struct {
+	IdMeal   string   `json:"idMeal"`
+	StrMeal  string   `json:"strMeal"`
+	Values   Values   `json:"values"`
+	Coverage Coverage `json:"coverage"`
+}
+
+// PlanNutrition is the estimate for a whole plan
+type PlanNutrition struct {
+	Meals    []MealNutrition `json:"meals"`
+	Week     Values          `json:"week"`
+	PerMeal  Values          `json:"per_meal"` // average of the meals
+	Coverage Coverage        `json:"coverage"`
+}
+
+// LoadCSV reads a nutrition table with the columns ingredient, unit, per, kcal, protein_g, fat_g, carbs_g.
+// Lines starting with # are comments. The unit is one the shopping list standardizes to, like g, ml, count or Can.
+func LoadCSV(r io.Reader) (Table, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 7
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty nutrition table")
+	}
+
+	t := Table{}
+	for i, record := range records[1:] {
+		numbers := make([]float64, 5)
+		for j, field := range record[2:] {
+			numbers[j], err = strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+2, err)
+			}
+		}
+		if numbers[0] <= 0 {
+			return nil, fmt.Errorf("line %d: per must be positive", i+2)
+		}
+		name := shoppinglist.NormalizeIngredient(record[0])
+		if t[name] == nil {
+			t[name] = make(map[string]entry)
+		}
+		t[name][strings.TrimSpace(record[1])] = entry{
+			per:    numbers[0],
+			values: Values{Calories: numbers[1], Protein: numbers[2], Fat: numbers[3], Carbs: numbers[4]},
+		}
+	}
+	return t, nil
+}
+
+// LoadFile reads a nutrition table from a CSV file
+func LoadFile(path string) (Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadCSV(f)
+}
+
+// lookup returns the nutrients of an amount of an ingredient. Grams and milliliters
+// are used interchangeably when only the other one is known, like the converter does for spoons.
+func (t Table) lookup(ingredient string, amount float64, unit string) (Values, bool) {
+	units := t[shoppinglist.NormalizeIngredient(ingredient)]
+	e, ok := units[unit]
+	if !ok {
+		switch unit {
+		case "g":
+			e, ok = units["ml"]
+		case "ml":
+			e, ok = units["g"]
+		}
+	}
+	if !ok {
+		return Values{}, false
+	}
+	return e.values.scale(amount / e.per), true
+}
+
+// Meal estimates the nutrients of a single meal from its standardized ingredient amounts
+func (t Table) Meal(meal models.Meal) MealNutrition {
+	converter := shoppinglist.IngredientConverter{}
+	items := converter.ConvertMealsToItems([]models.Meal{meal}, nil, nil)
+
+	result := MealNutrition{IdMeal: meal.IdMeal, StrMeal: meal.StrMeal, Coverage: Coverage{Missing: []string{}}}
+	for _, item := range items {
+		result.Coverage.Total++
+		values, ok := t.lookup(item.Ingredient, item.Amount, item.Unit)
+		if !ok {
+			result.Coverage.Missing = append(result.Coverage.Missing, item.Ingredient)
+			continue
+		}
+		result.Coverage.Covered++
+		result.Values = result.Values.add(values)
+	}
+	result.Values = result.Values.round()
+	return result
+}
+
+// Plan estimates the nutrients of every meal, the whole week and the average meal
+func (t Table) Plan(meals []models.Meal) PlanNutrition {
+	result := PlanNutrition{Meals: []MealNutrition{}, Coverage: Coverage{Missing: []string{}}}
+	missing := make(map[string]bool)
+	for _, meal := range meals {
+		mn := t.Meal(meal)
+		result.Meals = append(result.Meals, mn)
+		result.Week = result.Week.add(mn.Values)
+		result.Coverage.Covered += mn.Coverage.Covered
+		result.Coverage.Total += mn.Coverage.Total
+		for _, name := range mn.Coverage.Missing {
+			if !missing[name] {
+				missing[name] = true
+				result.Coverage.Missing = append(result.Coverage.Missing, name)
+			}
+		}
+	}
+	if len(meals) > 0 {
+		result.PerMeal = result.Week.scale(1 / float64(len(meals))).round()
+	}
+	result.Week = result.Week.round()
+	return result
+}
+
+func (v Values) add(o Values) Values {
+	return Values{
+		Calories: v.Calories + o.Calories,
+		Protein:  v.Protein + o.Protein,
+		Fat:      v.Fat + o.Fat,
+		Carbs:    v.Carbs + o.Carbs,
+	}
+}
+
+func (v Values) scale(f float64) Values {
+	return Values{
+		Calories: v.Calories * f,
+		Protein:  v.Protein * f,
+		Fat:      v.Fat * f,
+		Carbs:    v.Carbs * f,
+	}
+}
+
+// round rounds all values to one decimal, the estimates are not more precise than that
+func (v Values) round() Values {
+	r := func(f float64) float64 { return math.Round(f*10) / 10 }
+	return Values{
+		Calories: r(v.Calories),
+		Protein:  r(v.Protein),
+		Fat:      r(v.Fat),
+		Carbs:    r(v.Carbs),
+	}
+}
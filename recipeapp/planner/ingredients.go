@@ -0,0 +1,72 @@
+package planner
+
+import (
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+	"sort"
+	"strings"
+)
+
+// alwaysAvailable ingredients are never counted as missing
+var alwaysAvailable = map[string]bool{
+	"water": true,
+	"salt":  true,
+}
+
+// Match is a meal ranked by how many of the available ingredients it uses
+type Match struct {
+	Meal    models.Meal         `json:"recipe"`
+	Used    []string            `json:"used"`
+	Missing []models.Ingredient `json:"missing"`
+}
+
+// RankByIngredients ranks meals by the number of available ingredients they use,
+// then by the fewest missing ingredients
+func RankByIngredients(have []string, meals []models.Meal) []Match {
+	available := make([]string, 0, len(have))
+	for _, ingredient := range have {
+		if name := shoppinglist.NormalizeIngredient(ingredient); name != "" {
+			available = append(available, name)
+		}
+	}
+
+	matches := make([]Match, 0, len(meals))
+	for _, meal := range meals {
+		match := Match{Meal: meal, Used: []string{}, Missing: []models.Ingredient{}}
+		for _, ingredient := range meal.Ingredients() {
+			name := shoppinglist.NormalizeIngredient(ingredient.Name)
+			switch {
+			case IngredientMatches(available, name):
+				match.Used = append(match.Used, ingredient.Name)
+			case !alwaysAvailable[name]:
+				match.Missing = append(match.Missing, ingredient)
+			}
+		}
+		if len(match.Used) > 0 {
+			matches = append(matches, match)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if len(matches[i].Used) != len(matches[j].Used) {
+			return len(matches[i].Used) > len(matches[j].Used)
+		}
+		if len(matches[i].Missing) != len(matches[j].Missing) {
+			return len(matches[i].Missing) < len(matches[j].Missing)
+		}
+		return matches[i].Meal.StrMeal < matches[j].Meal.StrMeal
+	})
+	return matches
+}
+
+// IngredientMatches reports whether a normalized ingredient name is covered by one of the
+// available ingredients, either exactly or as whole words ("chicken" covers "chicken breast")
+func IngredientMatches(available []string, name string) bool {
+	padded := " " + name + " "
+	for _, have := range available {
+		if have == name || strings.Contains(padded, " "+have+" ") || strings.Contains(padded, " "+have+"s ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package planner
+
+import (
+	"recipeapp/models"
+	"strings"
+	"testing"
+)
+
+func TestIngredientMatches(t *testing.T) {
+	available := []string{"chicken", "egg", "red onion"}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"chicken", true},
+		{"chicken breast", true},
+		{"boneless chicken thighs", true},
+		{"eggs", true},
+		{"red onion", true},
+		{"onion", false},
+		{"chickpeas", false},
+		{"eggplant", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IngredientMatches(available, test.name); got != test.want {
+			t.Errorf("%q matches %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRankByIngredients(t *testing.T) {
+	meals := []models.Meal{
+		meal("1", "Beef", "British", "beef", "potato", "carrot", "salt"),
+		meal("2", "Chicken", "Thai", "chicken breast", "rice", "water"),
+		meal("3", "Chicken", "Indian", "Chicken Thighs", "Rice", "yoghurt", "garam masala"),
+		meal("4", "Chicken", "British", "chicken", "leek", "potato"),
+		meal("5", "Pasta", "Italian", "spaghetti", "tomato"),
+	}
+	matches := RankByIngredients([]string{" Chicken ", "rice", "", "potato"}, meals)
+	want := []struct {
+		id      string
+		used    string
+		missing string
+	}{
+		{"2", "chicken breast, rice", ""},
+		{"4", "chicken, potato", "leek"},
+		{"3", "Chicken Thighs, Rice", "yoghurt, garam masala"},
+		{"1", "potato", "beef, carrot"},
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("%d matches, want %d", len(matches), len(want))
+	}
+	for i, w := range want {
+		match := matches[i]
+		missing := make([]string, 0, len(match.Missing))
+		for _, ingredient := range match.Missing {
+			missing = append(missing, ingredient.Name)
+		}
+		if match.Meal.IdMeal != w.id || strings.Join(match.Used, ", ") != w.used || strings.Join(missing, ", ") != w.missing {
+			t.Errorf("match %d: meal %s using %q missing %q, want meal %s using %q missing %q", i, match.Meal.IdMeal,
+				match.Used, missing, w.id, w.used, w.missing)
+		}
+	}
+
+	if matches := RankByIngredients(nil, meals); len(matches) != 0 {
+		t.Errorf("%d matches without ingredients", len(matches))
+	}
+}
@@ -0,0 +1,201 @@
+package planner
+
+import (
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+)
+
+// maxSwapRounds bounds the local search after the greedy selection
+const maxSwapRounds = 50
+
+// OverlapScore describes how well the meals of a plan share their ingredients
+type OverlapScore struct {
+	DistinctIngredients int     `json:"distinct_ingredients"` // length of the shopping list
+	IngredientUses      int     `json:"ingredient_uses"`      // ingredients summed over all meals
+	SharedIngredients   int     `json:"shared_ingredients"`   // ingredients used by more than one meal
+	Score               float64 `json:"score"`                // share of ingredient uses covered by an earlier purchase, 0 to 1
+}
+
+// GenerateOverlap selects the plan from the candidate pool so the meals share as many ingredients as possible
+func (p *Planner) GenerateOverlap(prefs Preferences) (Plan, error) {
+	prefs.KnownGood = sortKnownGood(prefs.KnownGood)
+	prefs.Candidates = prefs.tagged(prefs.Candidates)
+	plan := Plan{Meals: []models.Meal{}}
+	pool, err := p.candidatePool(prefs, &plan)
+	if err != nil {
+		return plan, err
+	}
+	if len(pool) < prefs.size() {
+		return plan, prefs.notEnoughMeals()
+	}
+	if err := prefs.Variety.check(prefs.size()); err != nil {
+		return plan, err
+	}
+
+	plan.Meals, err = selectOverlapping(pool, prefs.size(), prefs.Variety)
+	if err != nil {
+		return plan, err
+	}
+	if err := p.fitBudget(&plan, prefs, pool); err != nil {
+		return plan, err
+	}
+	score := ScoreOverlap(plan.Meals)
+	plan.Overlap = &score
+	return plan, nil
+}
+
+// selectOverlapping picks size meals from the pool greedily by overlap score
+// and improves the selection by swapping meals until no swap helps anymore.
+// Only selections keeping the variety rules are considered.
+func selectOverlapping(pool []models.Meal, size int, variety Variety) ([]models.Meal, error) {
+	ingredients := make([][]string, len(pool))
+	for i, meal := range pool {
+		ingredients[i] = ingredientSet(meal)
+	}
+	mealsOf := func(selected []int) []models.Meal {
+		meals := make([]models.Meal, 0, len(selected))
+		for _, i := range selected {
+			meals = append(meals, pool[i])
+		}
+		return meals
+	}
+
+	first := firstOverlapping(ingredients, pool, variety, size)
+	if first < 0 {
+		misses := varietyMisses{}
+		for _, meal := range pool {
+			misses.add(variety.fits(nil, meal, size))
+		}
+		return nil, misses.err(len(pool))
+	}
+	selected := []int{first}
+	chosen := map[int]bool{first: true}
+	for len(selected) < size {
+		best, bestScore := -1, -1.0
+		misses := varietyMisses{}
+		for i := range pool {
+			if chosen[i] {
+				continue
+			}
+			if broken := variety.fits(mealsOf(selected), pool[i], size); len(broken) > 0 {
+				misses.add(broken)
+				continue
+			}
+			if score := overlapOf(ingredients, append(selected, i)).Score; score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			return nil, misses.err(len(pool))
+		}
+		selected = append(selected, best)
+		chosen[best] = true
+	}
+
+	current := overlapOf(ingredients, selected)
+	for round := 0; round < maxSwapRounds; round++ {
+		improved := false
+		for pos := range selected {
+			for i := range pool {
+				if chosen[i] {
+					continue
+				}
+				old := selected[pos]
+				selected[pos] = i
+				if candidate := overlapOf(ingredients, selected); better(candidate, current) && variety.valid(mealsOf(selected)) {
+					current = candidate
+					delete(chosen, old)
+					chosen[i] = true
+					improved = true
+					continue
+				}
+				selected[pos] = old
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return mealsOf(selected), nil
+}
+
+// firstOverlapping returns the meal sharing the most ingredients with the rest of the pool
+// that can start a plan under the variety rules, -1 if none can
+func firstOverlapping(ingredients [][]string, pool []models.Meal, variety Variety, size int) int {
+	counts := make(map[string]int)
+	for _, set := range ingredients {
+		for _, name := range set {
+			counts[name]++
+		}
+	}
+	best, bestShared := -1, -1
+	for i, set := range ingredients {
+		if len(variety.fits(nil, pool[i], size)) > 0 {
+			continue
+		}
+		shared := 0
+		for _, name := range set {
+			shared += counts[name] - 1
+		}
+		if shared > bestShared {
+			best, bestShared = i, shared
+		}
+	}
+	return best
+}
+
+// better prefers the higher score and, on a tie, the shorter shopping list
+func better(a, b OverlapScore) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.DistinctIngredients < b.DistinctIngredients
+}
+
+// ScoreOverlap scores how well the meals share their ingredients
+func ScoreOverlap(meals []models.Meal) OverlapScore {
+	ingredients := make([][]string, len(meals))
+	selected := make([]int, len(meals))
+	for i, meal := range meals {
+		ingredients[i] = ingredientSet(meal)
+		selected[i] = i
+	}
+	return overlapOf(ingredients, selected)
+}
+
+func overlapOf(ingredients [][]string, selected []int) OverlapScore {
+	counts := make(map[string]int)
+	uses := 0
+	for _, i := range selected {
+		for _, name := range ingredients[i] {
+			counts[name]++
+			uses++
+		}
+	}
+	score := OverlapScore{DistinctIngredients: len(counts), IngredientUses: uses}
+	for _, count := range counts {
+		if count > 1 {
+			score.SharedIngredients++
+		}
+	}
+	if uses > 0 {
+		score.Score = float64(uses-len(counts)) / float64(uses)
+	}
+	return score
+}
+
+// ingredientSet returns the normalized ingredient names of a meal that have to be bought
+func ingredientSet(meal models.Meal) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, ingredient := range meal.Ingredients() {
+		name := shoppinglist.NormalizeIngredient(ingredient.Name)
+		if alwaysAvailable[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,153 @@
+package planner
+
+import (
+	"errors"
+	"recipeapp/models"
+	"sort"
+	"testing"
+)
+
+// meal returns a meal of the category and area with the ingredients
+func meal(id, category, area string, ingredients ...string) models.Meal {
+	m := models.Meal{IdMeal: id, StrMeal: "Meal " + id, StrCategory: category, StrArea: area}
+	list := make([]models.Ingredient, 0, len(ingredients))
+	for _, name := range ingredients {
+		list = append(list, models.Ingredient{Name: name, Measure: "1"})
+	}
+	m.SetIngredients(list)
+	return m
+}
+
+// planIDs returns the ids of the meals in the order of the plan
+func planIDs(meals []models.Meal) []string {
+	list := make([]string, 0, len(meals))
+	for _, m := range meals {
+		list = append(list, m.IdMeal)
+	}
+	return list
+}
+
+// ids returns the sorted ids of the meals
+func ids(meals []models.Meal) []string {
+	list := planIDs(meals)
+	sort.Strings(list)
+	return list
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestScoreOverlap(t *testing.T) {
+	tests := []struct {
+		name  string
+		meals []models.Meal
+		want  OverlapScore
+	}{
+		{"no meals", nil, OverlapScore{}},
+		{"nothing shared", []models.Meal{meal("1", "", "", "leek"), meal("2", "", "", "rice")},
+			OverlapScore{DistinctIngredients: 2, IngredientUses: 2}},
+		{"all shared", []models.Meal{meal("1", "", "", "leek", "rice"), meal("2", "", "", " Leek ", "rice")},
+			OverlapScore{DistinctIngredients: 2, IngredientUses: 4, SharedIngredients: 2, Score: 0.5}},
+		{"water, salt and repeats are not bought", []models.Meal{meal("1", "", "", "leek", "water", "leek"),
+			meal("2", "", "", "salt", "rice")},
+			OverlapScore{DistinctIngredients: 2, IngredientUses: 2}},
+		{"one of three shared", []models.Meal{meal("1", "", "", "leek"), meal("2", "", "", "leek"),
+			meal("3", "", "", "rice")},
+			OverlapScore{DistinctIngredients: 2, IngredientUses: 3, SharedIngredients: 1, Score: 1.0 / 3}},
+	}
+	for _, test := range tests {
+		if got := ScoreOverlap(test.meals); got != test.want {
+			t.Errorf("%s: %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBetter(t *testing.T) {
+	tests := []struct {
+		a, b OverlapScore
+		want bool
+	}{
+		{OverlapScore{Score: 0.5, DistinctIngredients: 9}, OverlapScore{Score: 0.4, DistinctIngredients: 3}, true},
+		{OverlapScore{Score: 0.4}, OverlapScore{Score: 0.5}, false},
+		{OverlapScore{Score: 0.5, DistinctIngredients: 3}, OverlapScore{Score: 0.5, DistinctIngredients: 4}, true},
+		{OverlapScore{Score: 0.5, DistinctIngredients: 4}, OverlapScore{Score: 0.5, DistinctIngredients: 4}, false},
+	}
+	for _, test := range tests {
+		if got := better(test.a, test.b); got != test.want {
+			t.Errorf("better(%+v, %+v) = %v", test.a, test.b, got)
+		}
+	}
+}
+
+func TestSelectOverlapping(t *testing.T) {
+	tests := []struct {
+		name string
+		pool []models.Meal
+		size int
+		want []string
+	}{
+		{
+			// the meal sharing the most starts, the one adding the fewest new ingredients follows
+			name: "greedy",
+			pool: []models.Meal{meal("1", "", "", "leek", "potato"), meal("2", "", "", "rice"),
+				meal("3", "", "", "leek", "potato", "cream"), meal("4", "", "", "potato", "bacon")},
+			size: 2,
+			want: []string{"1", "3"},
+		},
+		{
+			// greedy takes 1 and 5 for the garlic and ends with 1/6, swapping in the tofu meals reaches 1/3
+			name: "swap",
+			pool: []models.Meal{meal("1", "", "", "chicken", "garlic", "rice"), meal("2", "", "", "tofu"),
+				meal("3", "", "", "leek"), meal("4", "", "", "tofu"), meal("5", "", "", "garlic", "lemon")},
+			size: 3,
+			want: []string{"2", "3", "4"},
+		},
+		{
+			name: "whole pool",
+			pool: []models.Meal{meal("1", "", "", "leek"), meal("2", "", "", "rice")},
+			size: 2,
+			want: []string{"1", "2"},
+		},
+	}
+	for _, test := range tests {
+		meals, err := selectOverlapping(test.pool, test.size, Variety{})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := ids(meals); !equalIDs(got, test.want) {
+			t.Errorf("%s: selected %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGenerateOverlap(t *testing.T) {
+	candidates := []models.Meal{meal("1", "Beef", "British", "leek", "beef"), meal("2", "Beef", "British", "leek", "beef"),
+		meal("3", "Pasta", "Italian", "spaghetti"), meal("4", "Pasta", "Italian", "leek", "spaghetti")}
+	plan, err := Deterministic(1).GenerateOverlap(Preferences{Candidates: candidates,
+		Schedule: Schedule{{Kind: Cook}, {Kind: Cook}, {Kind: EatingOut}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(plan.Meals); !equalIDs(got, []string{"1", "2"}) {
+		t.Errorf("plan %v, want the two beef meals", got)
+	}
+	if plan.Overlap == nil || plan.Overlap.Score != 0.5 {
+		t.Errorf("overlap %+v of the plan", plan.Overlap)
+	}
+
+	_, err = Deterministic(1).GenerateOverlap(Preferences{Candidates: candidates[:1],
+		Schedule: Schedule{{Kind: Cook}, {Kind: Cook}}})
+	if !errors.Is(err, ErrNotEnoughMeals) {
+		t.Errorf("%v for too few candidates, want ErrNotEnoughMeals", err)
+	}
+}
@@ -0,0 +1,365 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"recipeapp/models"
+	"sort"
+	"strings"
+)
+
+// PlanSize is the number of dinners in a plan
+const PlanSize = 7
+
+// DefaultKnownGoodRatio is the share of a plan taken from known good meals if nothing else is asked for
+const DefaultKnownGoodRatio = 0.3
+
+// maxDiscoveryAttempts limits how many random meals are fetched per plan before giving up
+const maxDiscoveryAttempts = 100
+
+var ErrNotEnoughMeals = errors.New("not enough meals to fill the plan")
+
+var ErrOverBudget = errors.New("no plan found within the budget")
+
+// ErrTooFewTagged is returned when the candidates with the included tags cannot fill the plan
+var ErrTooFewTagged = fmt.Errorf("%w with the tags", ErrNotEnoughMeals)
+
+// excludedCategories are never planned as dinner
+var excludedCategories = map[string]bool{
+	"Dessert":       true,
+	"Side":          true,
+	"Miscellaneous": true,
+	"Starter":       true,
+}
+
+// ExcludedCategory reports whether meals of the category are never planned as dinner
+func ExcludedCategory(category string) bool {
+	return excludedCategories[category]
+}
+
+// WeightedMeal is a known good meal, meals with a higher weight are picked more often
+type WeightedMeal struct {
+	Meal   models.Meal
+	Weight float64
+}
+
+// Preferences steer the selection of a single plan
+type Preferences struct {
+	KnownGood      []WeightedMeal             // favourites, well-rated and own meals
+	Blocked        map[string]bool            // ids of meals that are never planned
+	KnownGoodRatio float64                    // share of the plan taken from KnownGood, 0 to 1
+	Filter         func(models.Meal) []string // returns why a meal must not be planned, nil to accept it
+	Candidates     []models.Meal              // cached meals the optimizations can choose from
+	Budget         float64                    // maximum cost of the plan, 0 for no limit
+	Cost           func(models.Meal) float64  // estimated cost of a meal, required for a budget
+	IncludeTags    []string                   // if set, only meals with at least one of these tags are planned, see Generate
+	ExcludeTags    []string                   // meals with one of these tags are never planned
+	Schedule       Schedule                   // leftovers and eating out days, every day is cooked if empty
+	Variety        Variety                    // limits on similar meals
+}
+
+// size returns the number of meals to plan, one per cooking day
+func (prefs Preferences) size() int {
+	if len(prefs.Schedule) == 0 {
+		return PlanSize
+	}
+	return prefs.Schedule.CookingDays()
+}
+
+// portions returns how often the meal at an index of the plan is eaten
+func (prefs Preferences) portions(index int) float64 {
+	if portions := prefs.Schedule.Portions(); index < len(portions) {
+		return portions[index]
+	}
+	return 1
+}
+
+// Rejection is a meal that was considered for the plan but not taken
+type Rejection struct {
+	IdMeal      string   `json:"idMeal"`
+	StrMeal     string   `json:"strMeal"`
+	StrCategory string   `json:"strCategory,omitempty"`
+	Reasons     []string `json:"reasons"`
+}
+
+// Plan is the result of a plan generation
+type Plan struct {
+	Meals    []models.Meal
+	Rejected []Rejection   // for debugging why meals were left out
+	Overlap  *OverlapScore // set if the plan was optimized for shared ingredients
+}
+
+// Planner selects the meals of a plan
+type Planner struct {
+	Random func() (models.Meal, error) // fetches a random meal for discovery
+	Rand   *rand.Rand                  // source of randomness, the global source is used if nil
+	Local  bool                        // discover meals from the candidates instead of Random, see Deterministic
+
+	// RemoteDiscoveries is the number of discoveries a local planner still makes with Random, so new meals
+	// keep arriving once the candidates are enough for a plan. If Random fails the candidates are used.
+	RemoteDiscoveries int
+	remote            int // discoveries made with Random so far
+}
+
+// Deterministic returns a planner that draws all meals from the candidates of the preferences.
+// With the same seed, preferences and candidates it always generates the same plan.
+func Deterministic(seed int64) *Planner {
+	return &Planner{Rand: rand.New(rand.NewSource(seed)), Local: true}
+}
+
+// Generate selects the meals of a new plan. Each dinner is either a known good meal,
+// picked weighted by its weight, or a newly discovered random meal.
+// Random meals rarely have tags, plans with IncludeTags should come from a local planner. It only draws
+// the candidates with the tags and fails right away with ErrTooFewTagged if they cannot fill the plan.
+func (p *Planner) Generate(prefs Preferences) (Plan, error) {
+	prefs.KnownGood = sortKnownGood(prefs.KnownGood)
+	prefs.Candidates = prefs.tagged(prefs.Candidates)
+	plan := Plan{Meals: []models.Meal{}}
+	knownGood := make([]WeightedMeal, 0, len(prefs.KnownGood))
+	for _, wm := range prefs.KnownGood {
+		if wm.Weight <= 0 {
+			continue
+		}
+		if reasons := rejectReasons(wm.Meal, prefs); len(reasons) > 0 {
+			plan.reject(wm.Meal, reasons)
+			continue
+		}
+		knownGood = append(knownGood, wm)
+	}
+
+	size := prefs.size()
+	if err := prefs.Variety.check(size); err != nil {
+		return plan, err
+	}
+	if p.Local && p.RemoteDiscoveries == 0 && countMeals(knownGood, prefs.Candidates) < size {
+		return plan, prefs.notEnoughMeals()
+	}
+	misses := varietyMisses{}
+	considered := len(knownGood)
+
+	planned := make(map[string]bool)
+	attempts := 0
+	for len(plan.Meals) < size {
+		if len(knownGood) > 0 && p.float64() < prefs.KnownGoodRatio {
+			i := p.pickWeighted(knownGood)
+			meal := knownGood[i].Meal
+			knownGood = append(knownGood[:i], knownGood[i+1:]...)
+			if broken := prefs.Variety.fits(plan.Meals, meal, size); len(broken) > 0 {
+				plan.reject(meal, reasonsOf(broken))
+				misses.add(broken)
+				continue
+			}
+			plan.Meals = append(plan.Meals, meal)
+			planned[meal.IdMeal] = true
+			continue
+		}
+
+		if attempts == maxDiscoveryAttempts {
+			if len(misses) > 0 {
+				return plan, misses.err(considered)
+			}
+			return plan, prefs.notEnoughMeals()
+		}
+		attempts++
+		meal, err := p.discover(prefs)
+		if err != nil {
+			return plan, err
+		}
+		if planned[meal.IdMeal] {
+			continue
+		}
+		considered++
+		if reasons := rejectReasons(meal, prefs); len(reasons) > 0 {
+			plan.reject(meal, reasons)
+			continue
+		}
+		if broken := prefs.Variety.fits(plan.Meals, meal, size); len(broken) > 0 {
+			plan.reject(meal, reasonsOf(broken))
+			misses.add(broken)
+			continue
+		}
+		plan.Meals = append(plan.Meals, meal)
+		planned[meal.IdMeal] = true
+		knownGood = removeMeal(knownGood, meal.IdMeal)
+	}
+	return plan, p.fitBudget(&plan, prefs, nil)
+}
+
+// rejectReasons returns why a meal cannot be planned: an unwanted category,
+// blocked by the user, not passing the tags, planned recently or not passing the filter
+func rejectReasons(meal models.Meal, prefs Preferences) []string {
+	var reasons []string
+	if ExcludedCategory(meal.StrCategory) {
+		reasons = append(reasons, "category "+meal.StrCategory+" is not planned as dinner")
+	}
+	if prefs.Blocked[meal.IdMeal] {
+		reasons = append(reasons, "blocked by the user")
+	}
+	reasons = append(reasons, tagReasons(meal, prefs)...)
+	reasons = append(reasons, prefs.Variety.recentReasons(meal)...)
+	if prefs.Filter != nil {
+		reasons = append(reasons, prefs.Filter(meal)...)
+	}
+	return reasons
+}
+
+// tagReasons returns why a meal does not pass the included and excluded tags
+func tagReasons(meal models.Meal, prefs Preferences) []string {
+	if len(prefs.IncludeTags) == 0 && len(prefs.ExcludeTags) == 0 {
+		return nil
+	}
+	tags := make(map[string]bool)
+	for _, tag := range meal.Tags() {
+		tags[tag] = true
+	}
+	var reasons []string
+	for _, tag := range prefs.ExcludeTags {
+		if tags[models.NormalizeTag(tag)] {
+			reasons = append(reasons, "tagged "+tag)
+		}
+	}
+	if len(prefs.IncludeTags) == 0 || prefs.hasIncludedTag(meal) {
+		return reasons
+	}
+	return append(reasons, "none of the tags "+strings.Join(prefs.IncludeTags, ", "))
+}
+
+// hasIncludedTag reports whether a meal has one of the included tags
+func (prefs Preferences) hasIncludedTag(meal models.Meal) bool {
+	for _, tag := range meal.Tags() {
+		for _, included := range prefs.IncludeTags {
+			if tag == models.NormalizeTag(included) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reject records a meal that was left out, each meal only once
+func (plan *Plan) reject(meal models.Meal, reasons []string) {
+	for _, r := range plan.Rejected {
+		if r.IdMeal == meal.IdMeal {
+			return
+		}
+	}
+	plan.Rejected = append(plan.Rejected, Rejection{IdMeal: meal.IdMeal, StrMeal: meal.StrMeal,
+		StrCategory: meal.StrCategory, Reasons: reasons})
+}
+
+// pickWeighted returns the index of a meal, chosen with a probability proportional to its weight
+func (p *Planner) pickWeighted(meals []WeightedMeal) int {
+	total := 0.0
+	for _, wm := range meals {
+		total += wm.Weight
+	}
+	r := p.float64() * total
+	for i, wm := range meals {
+		r -= wm.Weight
+		if r < 0 {
+			return i
+		}
+	}
+	return len(meals) - 1
+}
+
+// discover returns a random meal, from the candidates for a local planner and from Random otherwise.
+// The first RemoteDiscoveries meals of a local planner come from Random as well.
+func (p *Planner) discover(prefs Preferences) (models.Meal, error) {
+	if !p.Local {
+		return p.Random()
+	}
+	if p.Random != nil && p.remote < p.RemoteDiscoveries {
+		p.remote++
+		if meal, err := p.Random(); err == nil {
+			return meal, nil
+		}
+		p.remote = p.RemoteDiscoveries
+	}
+	if len(prefs.Candidates) == 0 {
+		return models.Meal{}, prefs.notEnoughMeals()
+	}
+	return prefs.Candidates[p.intn(len(prefs.Candidates))], nil
+}
+
+// tagged returns the meals with one of the included tags, all meals if no tags are included
+func (prefs Preferences) tagged(meals []models.Meal) []models.Meal {
+	if len(prefs.IncludeTags) == 0 {
+		return meals
+	}
+	var tagged []models.Meal
+	for _, meal := range meals {
+		if prefs.hasIncludedTag(meal) {
+			tagged = append(tagged, meal)
+		}
+	}
+	return tagged
+}
+
+// notEnoughMeals returns the error for a plan that cannot be filled, ErrTooFewTagged if tags limit the meals
+func (prefs Preferences) notEnoughMeals() error {
+	if len(prefs.IncludeTags) > 0 {
+		return ErrTooFewTagged
+	}
+	return ErrNotEnoughMeals
+}
+
+// countMeals returns the number of distinct meals among the known good meals and the candidates
+func countMeals(knownGood []WeightedMeal, candidates []models.Meal) int {
+	ids := make(map[string]bool)
+	for _, wm := range knownGood {
+		ids[wm.Meal.IdMeal] = true
+	}
+	for _, meal := range candidates {
+		ids[meal.IdMeal] = true
+	}
+	return len(ids)
+}
+
+// sortKnownGood orders the known good meals by id, so the picks only depend on the source of randomness
+func sortKnownGood(meals []WeightedMeal) []WeightedMeal {
+	sorted := append([]WeightedMeal{}, meals...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Meal.IdMeal < sorted[j].Meal.IdMeal })
+	return sorted
+}
+
+func (p *Planner) intn(n int) int {
+	if p.Rand != nil {
+		return p.Rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+func (p *Planner) float64() float64 {
+	if p.Rand != nil {
+		return p.Rand.Float64()
+	}
+	return rand.Float64()
+}
+
+// removeMeal drops a meal from the known good meals so it is not planned twice
+func removeMeal(meals []WeightedMeal, id string) []WeightedMeal {
+	for i, wm := range meals {
+		if wm.Meal.IdMeal == id {
+			return append(meals[:i], meals[i+1:]...)
+		}
+	}
+	return meals
+}
+
+// RatingWeight returns how strongly a rated meal is preferred, 0 if it is not known good.
+// Favourites count 2, every star above 3 counts 1 and own recipes get a base weight of 1.
+func RatingWeight(favourite bool, rating int, own bool) float64 {
+	weight := 0.0
+	if favourite {
+		weight += 2
+	}
+	if rating > 3 {
+		weight += float64(rating - 3)
+	}
+	if own {
+		weight += 1
+	}
+	return weight
+}
@@ -0,0 +1,76 @@
+package planner
+
+import (
+	"errors"
+	"recipeapp/models"
+	"strings"
+	"testing"
+)
+
+// tagged returns the meal with the tags in its StrTags
+func tagged(m models.Meal, tags string) models.Meal {
+	m.StrTags = tags
+	return m
+}
+
+func TestTagReasons(t *testing.T) {
+	curry := tagged(meal("1", "Chicken", "Indian", "chicken"), "Spicy,Curry")
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    string
+	}{
+		{name: "no tags"},
+		{name: "included", include: []string{"quick", "Curry"}},
+		{name: "not included", include: []string{"quick", "baking"}, want: "none of the tags quick, baking"},
+		{name: "excluded", exclude: []string{"spicy"}, want: "tagged spicy"},
+		{name: "included and excluded", include: []string{"curry"}, exclude: []string{"Spicy"}, want: "tagged Spicy"},
+	}
+	for _, test := range tests {
+		reasons := tagReasons(curry, Preferences{IncludeTags: test.include, ExcludeTags: test.exclude})
+		if got := strings.Join(reasons, "; "); got != test.want {
+			t.Errorf("%s: reasons %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGenerateWithTags(t *testing.T) {
+	candidates := []models.Meal{
+		tagged(meal("1", "Beef", "British", "beef"), "quick"),
+		tagged(meal("2", "Chicken", "Thai", "chicken"), "Quick,Spicy"),
+		meal("3", "Pasta", "Italian", "spaghetti"),
+		tagged(meal("4", "Seafood", "Japanese", "salmon"), "quick"),
+		tagged(meal("5", "Pork", "Mexican", "pork"), "slow"),
+	}
+	schedule := Schedule{{Kind: Cook}, {Kind: Cook}, {Kind: Cook}}
+	generators := map[string]func(*Planner, Preferences) (Plan, error){
+		"random":  (*Planner).Generate,
+		"overlap": (*Planner).GenerateOverlap,
+	}
+	for name, generate := range generators {
+		plan, err := generate(Deterministic(1), Preferences{Candidates: candidates, Schedule: schedule,
+			IncludeTags: []string{"quick"}})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := strings.Join(ids(plan.Meals), " "); got != "1 2 4" {
+			t.Errorf("%s: plan %s, want the quick meals", name, got)
+		}
+
+		_, err = generate(Deterministic(1), Preferences{Candidates: candidates, Schedule: schedule,
+			IncludeTags: []string{"quick"}, ExcludeTags: []string{"spicy"}})
+		if !errors.Is(err, ErrTooFewTagged) || !errors.Is(err, ErrNotEnoughMeals) {
+			t.Errorf("%s: %v for two quick meals that are not spicy, want ErrTooFewTagged", name, err)
+		}
+	}
+
+	// without RemoteDiscoveries a local planner fails before drawing anything
+	calls := 0
+	planner := Deterministic(1)
+	planner.Random = func() (models.Meal, error) { calls++; return candidates[4], nil }
+	_, err := planner.Generate(Preferences{Candidates: candidates, Schedule: schedule, IncludeTags: []string{"slow"}})
+	if !errors.Is(err, ErrTooFewTagged) || calls != 0 {
+		t.Errorf("%v after %d random meals for one slow meal, want ErrTooFewTagged right away", err, calls)
+	}
+}
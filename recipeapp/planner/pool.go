@@ -0,0 +1,123 @@
+package planner
+
+import (
+	"fmt"
+	"recipeapp/models"
+	"sort"
+)
+
+// poolDiscoveries is the number of fresh random meals added to the candidate pool
+const poolDiscoveries = 2 * PlanSize
+
+// candidatePool collects the meals an optimization can choose from: the known good meals,
+// the cached candidates and fresh random meals. Meals that must not be planned are rejected.
+func (p *Planner) candidatePool(prefs Preferences, plan *Plan) ([]models.Meal, error) {
+	var pool []models.Meal
+	inPool := make(map[string]bool)
+	add := func(meal models.Meal) {
+		if inPool[meal.IdMeal] {
+			return
+		}
+		if reasons := rejectReasons(meal, prefs); len(reasons) > 0 {
+			plan.reject(meal, reasons)
+			return
+		}
+		inPool[meal.IdMeal] = true
+		pool = append(pool, meal)
+	}
+
+	for _, wm := range prefs.KnownGood {
+		add(wm.Meal)
+	}
+	for _, meal := range prefs.Candidates {
+		add(meal)
+	}
+	discovered := 0
+	for attempts := 0; discovered < poolDiscoveries && attempts < maxDiscoveryAttempts; attempts++ {
+		meal, err := p.discover(prefs)
+		if err != nil {
+			return nil, err
+		}
+		before := len(pool)
+		add(meal)
+		if len(pool) > before {
+			discovered++
+		}
+	}
+	return pool, nil
+}
+
+// fitBudget replaces the most expensive meals of the plan by cheaper ones from the pool, keeping the variety
+// rules, until the plan is within the budget. The pool is collected first if it is nil.
+func (p *Planner) fitBudget(plan *Plan, prefs Preferences, pool []models.Meal) error {
+	if prefs.Budget <= 0 || prefs.Cost == nil {
+		return nil
+	}
+	costs := make(map[string]float64)
+	cost := func(meal models.Meal) float64 {
+		if c, ok := costs[meal.IdMeal]; ok {
+			return c
+		}
+		costs[meal.IdMeal] = prefs.Cost(meal)
+		return costs[meal.IdMeal]
+	}
+	// meals eaten again as leftovers are cooked in larger amounts
+	total := 0.0
+	for i, meal := range plan.Meals {
+		total += cost(meal) * prefs.portions(i)
+	}
+	if total <= prefs.Budget {
+		return nil
+	}
+
+	if pool == nil {
+		var err error
+		pool, err = p.candidatePool(prefs, plan)
+		if err != nil {
+			return err
+		}
+	}
+	planned := make(map[string]bool)
+	for _, meal := range plan.Meals {
+		planned[meal.IdMeal] = true
+	}
+	var spare []models.Meal
+	for _, meal := range pool {
+		if !planned[meal.IdMeal] {
+			spare = append(spare, meal)
+		}
+	}
+	sort.SliceStable(spare, func(i, j int) bool { return cost(spare[i]) < cost(spare[j]) })
+
+	for total > prefs.Budget && len(spare) > 0 {
+		expensive := 0
+		for i, meal := range plan.Meals {
+			if cost(meal)*prefs.portions(i) > cost(plan.Meals[expensive])*prefs.portions(expensive) {
+				expensive = i
+			}
+		}
+		// the cheapest spare meal that saves money and keeps the variety rules
+		replacement := -1
+		for j, meal := range spare {
+			if cost(meal) >= cost(plan.Meals[expensive]) {
+				break
+			}
+			meals := append([]models.Meal{}, plan.Meals...)
+			meals[expensive] = meal
+			if prefs.Variety.valid(meals) {
+				replacement = j
+				break
+			}
+		}
+		if replacement < 0 {
+			break
+		}
+		total += (cost(spare[replacement]) - cost(plan.Meals[expensive])) * prefs.portions(expensive)
+		plan.Meals[expensive] = spare[replacement]
+		spare = append(spare[:replacement], spare[replacement+1:]...)
+	}
+	if total > prefs.Budget {
+		return fmt.Errorf("%w: the cheapest plan found costs %.2f", ErrOverBudget, total)
+	}
+	return nil
+}
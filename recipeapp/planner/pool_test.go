@@ -0,0 +1,82 @@
+package planner
+
+import (
+	"errors"
+	"recipeapp/models"
+	"strings"
+	"testing"
+)
+
+func TestFitBudget(t *testing.T) {
+	a := meal("a", "Beef", "British", "beef")
+	b := meal("b", "Chicken", "Thai", "chicken")
+	c := meal("c", "Chicken", "Indian", "chicken")
+	d := meal("d", "Pasta", "Italian", "spaghetti")
+	costs := map[string]float64{"a": 10, "b": 8, "c": 2, "d": 3}
+	cost := func(meal models.Meal) float64 { return costs[meal.IdMeal] }
+	all := []models.Meal{a, b, c, d}
+	leftovers := Schedule{{Kind: Cook}, {Kind: Leftovers, LeftoversOf: 1}, {Kind: Cook}}
+
+	tests := []struct {
+		name     string
+		plan     []models.Meal
+		pool     []models.Meal
+		budget   float64
+		variety  Variety
+		schedule Schedule
+		want     string
+		err      string
+	}{
+		{name: "no budget", plan: []models.Meal{a, b}, pool: all, want: "a b"},
+		{name: "within the budget", plan: []models.Meal{a, b}, pool: all, budget: 18, want: "a b"},
+		{name: "most expensive meal replaced by the cheapest", plan: []models.Meal{a, b}, pool: all, budget: 12,
+			want: "c b"},
+		{name: "replacement keeps the variety rules", plan: []models.Meal{a, b}, pool: all, budget: 12,
+			variety: Variety{MaxPerCategory: 1}, want: "d b"},
+		{name: "several replacements", plan: []models.Meal{a, b}, pool: all, budget: 5, want: "c d"},
+		// the chicken is eaten twice, 16 for both days is more than the 10 of the beef
+		{name: "leftovers count twice", plan: []models.Meal{b, a}, pool: all, budget: 14, schedule: leftovers,
+			want: "c a"},
+		{name: "no cheaper meals", plan: []models.Meal{a, b}, pool: []models.Meal{a, b}, budget: 3, want: "a b",
+			err: "the cheapest plan found costs 18.00"},
+		{name: "cheapest plan over the budget", plan: []models.Meal{a, b}, pool: all, budget: 4, want: "c d",
+			err: "the cheapest plan found costs 5.00"},
+	}
+	for _, test := range tests {
+		plan := Plan{Meals: append([]models.Meal{}, test.plan...)}
+		prefs := Preferences{Budget: test.budget, Cost: cost, Variety: test.variety, Schedule: test.schedule}
+		err := Deterministic(1).fitBudget(&plan, prefs, test.pool)
+		if test.err == "" && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if test.err != "" && (!errors.Is(err, ErrOverBudget) || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: %v, want ErrOverBudget with %q", test.name, err, test.err)
+		}
+		if got := strings.Join(planIDs(plan.Meals), " "); got != test.want {
+			t.Errorf("%s: plan %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+// TestFitBudgetCollectsPool checks that a plan generated without a pool is fitted with meals from the candidates
+func TestFitBudgetCollectsPool(t *testing.T) {
+	a := meal("a", "Beef", "British", "beef")
+	b := meal("b", "Chicken", "Thai", "chicken")
+	c := meal("c", "Pasta", "Italian", "spaghetti")
+	costs := map[string]float64{"a": 10, "b": 8, "c": 2}
+	prefs := Preferences{Candidates: []models.Meal{a, b, c}, Budget: 12,
+		Cost: func(meal models.Meal) float64 { return costs[meal.IdMeal] }}
+	plan := Plan{Meals: []models.Meal{a, b}}
+	if err := Deterministic(1).fitBudget(&plan, prefs, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(planIDs(plan.Meals), " "); got != "c b" {
+		t.Errorf("plan %s, want the beef replaced by the pasta", got)
+	}
+
+	prefs.Cost = nil
+	plan = Plan{Meals: []models.Meal{a, b}}
+	if err := Deterministic(1).fitBudget(&plan, prefs, nil); err != nil || plan.Meals[0].IdMeal != "a" {
+		t.Errorf("plan %v (%v) fitted without costs", planIDs(plan.Meals), err)
+	}
+}
@@ -0,0 +1,195 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"recipeapp/models"
+	"strconv"
+	"strings"
+)
+
+// DayKind tells what is eaten on a day of the plan
+type DayKind string
+
+const (
+	Cook      DayKind = "cook"       // a meal of the plan is cooked
+	Leftovers DayKind = "leftovers"  // the meal cooked on an earlier day is eaten again
+	EatingOut DayKind = "eating_out" // nothing is cooked
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Day is one day of the schedule
+type Day struct {
+	Kind        DayKind `json:"kind"`
+	LeftoversOf int     `json:"leftovers_of,omitempty"` // day number, starting at 1, whose meal is eaten again
+}
+
+// Schedule assigns a kind to every day of a plan. The meals of a plan belong to the cooking days in order,
+// an empty schedule means every meal is cooked on its own day.
+type Schedule []Day
+
+// PlannedDay is a day of a plan with the meal eaten on it, nil when eating out
+type PlannedDay struct {
+	Day         int          `json:"day"`
+	Kind        DayKind      `json:"kind"`
+	LeftoversOf int          `json:"leftovers_of,omitempty"`
+	Meal        *models.Meal `json:"meal"`
+}
+
+// CookingSchedule returns a schedule of n days with a meal cooked every day
+func CookingSchedule(n int) Schedule {
+	schedule := make(Schedule, n)
+	for i := range schedule {
+		schedule[i] = Day{Kind: Cook}
+	}
+	return schedule
+}
+
+// ParseSchedule builds a week from ?leftovers=2:1,5:4 (day 2 eats the leftovers of day 1) and ?eating_out=6,7.
+// Days are numbered from 1 to PlanSize, all other days are cooking days.
+func ParseSchedule(leftovers, eatingOut string) (Schedule, error) {
+	schedule := CookingSchedule(PlanSize)
+	for _, pair := range splitList(leftovers) {
+		day, source, found := strings.Cut(pair, ":")
+		if !found {
+			return nil, fmt.Errorf("%w: leftovers must be given as day:source, got %q", ErrInvalidSchedule, pair)
+		}
+		d, err := parseDay(day)
+		if err != nil {
+			return nil, err
+		}
+		s, err := parseDay(source)
+		if err != nil {
+			return nil, err
+		}
+		schedule[d-1] = Day{Kind: Leftovers, LeftoversOf: s}
+	}
+	for _, day := range splitList(eatingOut) {
+		d, err := parseDay(day)
+		if err != nil {
+			return nil, err
+		}
+		if schedule[d-1].Kind != Cook {
+			return nil, fmt.Errorf("%w: day %d is given twice", ErrInvalidSchedule, d)
+		}
+		schedule[d-1] = Day{Kind: EatingOut}
+	}
+	return schedule, schedule.Validate()
+}
+
+func splitList(s string) []string {
+	var parts []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func parseDay(s string) (int, error) {
+	day, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || day < 1 || day > PlanSize {
+		return 0, fmt.Errorf("%w: days are numbered from 1 to %d, got %q", ErrInvalidSchedule, PlanSize, s)
+	}
+	return day, nil
+}
+
+// Validate checks that leftovers come from an earlier cooking day and that at least one day is cooked
+func (s Schedule) Validate() error {
+	if len(s) == 0 {
+		return nil
+	}
+	for i, day := range s {
+		switch day.Kind {
+		case Cook, EatingOut:
+		case Leftovers:
+			source := day.LeftoversOf
+			if source < 1 || source > i {
+				return fmt.Errorf("%w: day %d can only eat the leftovers of an earlier day", ErrInvalidSchedule, i+1)
+			}
+			if s[source-1].Kind != Cook {
+				return fmt.Errorf("%w: nothing is cooked on day %d for the leftovers of day %d", ErrInvalidSchedule, source, i+1)
+			}
+		default:
+			return fmt.Errorf("%w: unknown kind %q on day %d", ErrInvalidSchedule, day.Kind, i+1)
+		}
+	}
+	if s.CookingDays() == 0 {
+		return fmt.Errorf("%w: at least one day must be cooked", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// CookingDays returns the number of days a meal is cooked, that is the number of meals of the plan
+func (s Schedule) CookingDays() int {
+	n := 0
+	for _, day := range s {
+		if day.Kind == Cook {
+			n++
+		}
+	}
+	return n
+}
+
+// MealIndex returns the index in the meals of the plan that is cooked on a day, starting at 1, or -1
+func (s Schedule) MealIndex(day int) int {
+	if day < 1 || day > len(s) || s[day-1].Kind != Cook {
+		return -1
+	}
+	index := 0
+	for _, d := range s[:day-1] {
+		if d.Kind == Cook {
+			index++
+		}
+	}
+	return index
+}
+
+// Portions returns how often each meal of the plan is eaten, once plus once per leftovers day
+func (s Schedule) Portions() []float64 {
+	portions := make([]float64, s.CookingDays())
+	for i := range portions {
+		portions[i] = 1
+	}
+	for _, day := range s {
+		if day.Kind == Leftovers {
+			if index := s.MealIndex(day.LeftoversOf); index >= 0 {
+				portions[index]++
+			}
+		}
+	}
+	return portions
+}
+
+// Servings maps the ids of the meals to how often they are eaten, the factor the shopping list scales them by
+func (s Schedule) Servings(meals []models.Meal) map[string]float64 {
+	servings := make(map[string]float64)
+	for i, portions := range s.Portions() {
+		if i < len(meals) && portions != 1 {
+			servings[meals[i].IdMeal] = portions
+		}
+	}
+	return servings
+}
+
+// Days lists every day of the plan with the meal eaten on it
+func (s Schedule) Days(meals []models.Meal) []PlannedDay {
+	if len(s) == 0 {
+		s = CookingSchedule(len(meals))
+	}
+	days := make([]PlannedDay, 0, len(s))
+	for i, day := range s {
+		planned := PlannedDay{Day: i + 1, Kind: day.Kind, LeftoversOf: day.LeftoversOf}
+		index := s.MealIndex(i + 1)
+		if day.Kind == Leftovers {
+			index = s.MealIndex(day.LeftoversOf)
+		}
+		if index >= 0 && index < len(meals) {
+			planned.Meal = &meals[index]
+		}
+		days = append(days, planned)
+	}
+	return days
+}
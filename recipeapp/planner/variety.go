@@ -0,0 +1,144 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"recipeapp/diet"
+	"recipeapp/models"
+	"sort"
+	"strings"
+)
+
+var ErrVariety = errors.New("the variety rules cannot be satisfied")
+
+// Variety limits how similar the meals of a plan may be, zero values mean no limit
+type Variety struct {
+	MaxPerCategory int             // meals of the same category, e.g. at most 2 "Chicken"
+	MaxPerArea     int             // meals of the same cuisine
+	MaxPerProtein  int             // meals with the same main protein
+	MinCuisines    int             // different cuisines in the plan
+	Recent         map[string]bool // ids of meals planned in the last RecentWeeks weeks, never repeated
+	RecentWeeks    int
+}
+
+// violation is a variety rule a meal breaks
+type violation struct {
+	rule   string
+	detail string
+}
+
+func (v violation) String() string {
+	return v.rule + ": " + v.detail
+}
+
+func reasonsOf(broken []violation) []string {
+	reasons := make([]string, 0, len(broken))
+	for _, v := range broken {
+		reasons = append(reasons, v.String())
+	}
+	return reasons
+}
+
+// IsEmpty reports whether the variety rules restrict anything
+func (v Variety) IsEmpty() bool {
+	return v.MaxPerCategory == 0 && v.MaxPerArea == 0 && v.MaxPerProtein == 0 && v.MinCuisines == 0 && len(v.Recent) == 0
+}
+
+// recentReasons returns why a meal must not be planned again this soon, checked like the other filters
+func (v Variety) recentReasons(meal models.Meal) []string {
+	if v.Recent[meal.IdMeal] {
+		return []string{fmt.Sprintf("no repeat within %d weeks: planned recently", v.RecentWeeks)}
+	}
+	return nil
+}
+
+// fits returns the rules that adding the meal to the selected meals of a plan with size meals would break
+func (v Variety) fits(selected []models.Meal, meal models.Meal, size int) []violation {
+	var broken []violation
+	count := func(key func(models.Meal) string, value string) int {
+		n := 0
+		for _, m := range selected {
+			if key(m) == value {
+				n++
+			}
+		}
+		return n
+	}
+	if v.MaxPerCategory > 0 && meal.StrCategory != "" && count(category, meal.StrCategory) >= v.MaxPerCategory {
+		broken = append(broken, violation{fmt.Sprintf("max %d per category", v.MaxPerCategory), meal.StrCategory + " is full"})
+	}
+	if v.MaxPerArea > 0 && meal.StrArea != "" && count(area, meal.StrArea) >= v.MaxPerArea {
+		broken = append(broken, violation{fmt.Sprintf("max %d per cuisine", v.MaxPerArea), meal.StrArea + " is full"})
+	}
+	if protein := diet.MainProtein(meal); v.MaxPerProtein > 0 && protein != "" && count(diet.MainProtein, protein) >= v.MaxPerProtein {
+		broken = append(broken, violation{fmt.Sprintf("max %d per protein", v.MaxPerProtein), protein + " is full"})
+	}
+	if v.MinCuisines > 0 {
+		cuisines := distinctAreas(selected)
+		needed := v.MinCuisines - len(cuisines)
+		if needed > 0 && needed >= size-len(selected) && (meal.StrArea == "" || cuisines[meal.StrArea]) {
+			broken = append(broken, violation{fmt.Sprintf("min %d cuisines", v.MinCuisines), "a new cuisine is needed"})
+		}
+	}
+	return broken
+}
+
+// valid reports whether a complete selection keeps all rules
+func (v Variety) valid(meals []models.Meal) bool {
+	for i := range meals {
+		if len(v.fits(meals[:i], meals[i], len(meals))) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// check fails early for rules no plan of the size can keep
+func (v Variety) check(size int) error {
+	if v.MinCuisines > size {
+		return fmt.Errorf("%w: %d cuisines do not fit into %d meals", ErrVariety, v.MinCuisines, size)
+	}
+	return nil
+}
+
+func category(meal models.Meal) string { return meal.StrCategory }
+
+func area(meal models.Meal) string { return meal.StrArea }
+
+func distinctAreas(meals []models.Meal) map[string]bool {
+	areas := make(map[string]bool)
+	for _, meal := range meals {
+		if meal.StrArea != "" {
+			areas[meal.StrArea] = true
+		}
+	}
+	return areas
+}
+
+// varietyMisses counts per rule how many meals it left out
+type varietyMisses map[string]int
+
+func (m varietyMisses) add(broken []violation) {
+	for _, v := range broken {
+		m[v.rule]++
+	}
+}
+
+// err explains which rules kept the plan from being filled from the available meals
+func (m varietyMisses) err(available int) error {
+	rules := make([]string, 0, len(m))
+	for rule := range m {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if m[rules[i]] != m[rules[j]] {
+			return m[rules[i]] > m[rules[j]]
+		}
+		return rules[i] < rules[j]
+	})
+	parts := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		parts = append(parts, fmt.Sprintf("%d meals left out by %s", m[rule], rule))
+	}
+	return fmt.Errorf("%w with %d available meals: %s", ErrVariety, available, strings.Join(parts, ", "))
+}
@@ -0,0 +1,149 @@
+package planner
+
+import (
+	"errors"
+	"recipeapp/models"
+	"strings"
+	"testing"
+)
+
+func TestVarietyFits(t *testing.T) {
+	chicken := meal("1", "Chicken", "British", "chicken thighs", "leek")
+	curry := meal("2", "Chicken", "Indian", "chicken breast", "rice")
+	tests := []struct {
+		name     string
+		variety  Variety
+		selected []models.Meal
+		meal     models.Meal
+		size     int
+		want     []string
+	}{
+		{"no rules", Variety{}, []models.Meal{chicken}, curry, 7, nil},
+		{"category full", Variety{MaxPerCategory: 1}, []models.Meal{chicken}, curry, 7,
+			[]string{"max 1 per category: Chicken is full"}},
+		{"category with room", Variety{MaxPerCategory: 2}, []models.Meal{chicken}, curry, 7, nil},
+		{"no category", Variety{MaxPerCategory: 1}, []models.Meal{meal("3", "", "", "leek")},
+			meal("4", "", "", "rice"), 7, nil},
+		{"cuisine full", Variety{MaxPerArea: 1}, []models.Meal{chicken}, meal("3", "Beef", "British", "beef"), 7,
+			[]string{"max 1 per cuisine: British is full"}},
+		{"protein full", Variety{MaxPerProtein: 1}, []models.Meal{meal("3", "Pasta", "Italian", "chicken")}, curry, 7,
+			[]string{"max 1 per protein: chicken is full"}},
+		{"stock is no protein", Variety{MaxPerProtein: 1}, []models.Meal{chicken},
+			meal("3", "Vegetarian", "Thai", "chicken stock", "tofu"), 7, nil},
+		{"new cuisine needed", Variety{MinCuisines: 3}, []models.Meal{chicken}, meal("3", "Beef", "British", "beef"), 3,
+			[]string{"min 3 cuisines: a new cuisine is needed"}},
+		{"meal without cuisine while one is needed", Variety{MinCuisines: 2}, []models.Meal{chicken},
+			meal("3", "Beef", "", "beef"), 2, []string{"min 2 cuisines: a new cuisine is needed"}},
+		{"cuisine can come later", Variety{MinCuisines: 3}, []models.Meal{chicken},
+			meal("3", "Beef", "British", "beef"), 4, nil},
+		{"new cuisine", Variety{MinCuisines: 3}, []models.Meal{chicken}, curry, 3, nil},
+		{"several rules", Variety{MaxPerCategory: 1, MaxPerArea: 1, MaxPerProtein: 1},
+			[]models.Meal{chicken}, meal("3", "Chicken", "British", "chicken"), 7,
+			[]string{"max 1 per category: Chicken is full", "max 1 per cuisine: British is full",
+				"max 1 per protein: chicken is full"}},
+	}
+	for _, test := range tests {
+		got := reasonsOf(test.variety.fits(test.selected, test.meal, test.size))
+		if strings.Join(got, "; ") != strings.Join(test.want, "; ") {
+			t.Errorf("%s: broken %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestVarietyValid(t *testing.T) {
+	british := meal("1", "Beef", "British", "beef")
+	pie := meal("2", "Beef", "British", "beef", "potato")
+	pasta := meal("3", "Pasta", "Italian", "spaghetti")
+	tests := []struct {
+		name    string
+		variety Variety
+		meals   []models.Meal
+		want    bool
+	}{
+		{"no rules", Variety{}, []models.Meal{british, pie}, true},
+		{"category repeated", Variety{MaxPerCategory: 1}, []models.Meal{british, pie}, false},
+		{"categories mixed", Variety{MaxPerCategory: 1}, []models.Meal{british, pasta}, true},
+		{"too few cuisines", Variety{MinCuisines: 2}, []models.Meal{british, pie}, false},
+		{"enough cuisines", Variety{MinCuisines: 2}, []models.Meal{pie, pasta}, true},
+	}
+	for _, test := range tests {
+		if got := test.variety.valid(test.meals); got != test.want {
+			t.Errorf("%s: valid %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestVarietyCheck(t *testing.T) {
+	if err := (Variety{MinCuisines: 7}).check(7); err != nil {
+		t.Errorf("7 cuisines in 7 meals: %v", err)
+	}
+	if err := (Variety{MinCuisines: 8}).check(7); !errors.Is(err, ErrVariety) {
+		t.Errorf("8 cuisines in 7 meals: %v, want ErrVariety", err)
+	}
+}
+
+func TestVarietyRecent(t *testing.T) {
+	variety := Variety{Recent: map[string]bool{"1": true}, RecentWeeks: 2}
+	if reasons := variety.recentReasons(meal("1", "Beef", "British")); len(reasons) != 1 ||
+		reasons[0] != "no repeat within 2 weeks: planned recently" {
+		t.Errorf("reasons %q for a recent meal", reasons)
+	}
+	if reasons := variety.recentReasons(meal("2", "Beef", "British")); reasons != nil {
+		t.Errorf("reasons %q for a meal not planned recently", reasons)
+	}
+}
+
+func TestVarietyMissesErr(t *testing.T) {
+	misses := varietyMisses{}
+	misses.add([]violation{{"max 1 per cuisine", "British is full"}, {"max 1 per category", "Beef is full"}})
+	misses.add([]violation{{"max 1 per cuisine", "Italian is full"}})
+	err := misses.err(5)
+	if !errors.Is(err, ErrVariety) {
+		t.Errorf("%v, want ErrVariety", err)
+	}
+	want := "with 5 available meals: 2 meals left out by max 1 per cuisine, 1 meals left out by max 1 per category"
+	if !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("error %q, want it to end with %q", err, want)
+	}
+}
+
+// TestSelectOverlappingVariety checks that the overlap selection keeps the variety rules and explains when it cannot
+func TestSelectOverlappingVariety(t *testing.T) {
+	pool := []models.Meal{meal("1", "Beef", "British", "beef", "leek"), meal("2", "Beef", "British", "beef", "leek"),
+		meal("3", "Pasta", "Italian", "spaghetti", "leek"), meal("4", "Seafood", "Thai", "prawns")}
+	meals, err := selectOverlapping(pool, 2, Variety{MaxPerCategory: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(meals); !equalIDs(got, []string{"1", "3"}) {
+		t.Errorf("selected %v, want one beef meal and the pasta sharing the leek", got)
+	}
+
+	_, err = selectOverlapping(pool[:2], 2, Variety{MaxPerArea: 1})
+	if !errors.Is(err, ErrVariety) || !strings.Contains(err.Error(), "1 meals left out by max 1 per cuisine") {
+		t.Errorf("%v for two British meals with one per cuisine, want ErrVariety", err)
+	}
+}
+
+// TestGenerateVariety checks that generated plans keep the variety rules and fail with ErrVariety otherwise
+func TestGenerateVariety(t *testing.T) {
+	candidates := []models.Meal{meal("1", "Beef", "British", "beef"), meal("2", "Beef", "British", "beef"),
+		meal("3", "Pasta", "Italian", "spaghetti"), meal("4", "Seafood", "Thai", "prawns")}
+	schedule := Schedule{{Kind: Cook}, {Kind: Cook}, {Kind: Cook}}
+	for seed := int64(1); seed <= 5; seed++ {
+		plan, err := Deterministic(seed).Generate(Preferences{Candidates: candidates, Schedule: schedule,
+			Variety: Variety{MinCuisines: 3}})
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if !(Variety{MinCuisines: 3}).valid(plan.Meals) {
+			t.Errorf("seed %d: plan %v with fewer than 3 cuisines", seed, ids(plan.Meals))
+		}
+	}
+
+	_, err := Deterministic(1).Generate(Preferences{Candidates: candidates, Schedule: schedule,
+		Variety: Variety{MaxPerArea: 1, MaxPerCategory: 1, Recent: map[string]bool{"3": true}}})
+	if !errors.Is(err, ErrVariety) {
+		t.Errorf("%v with only two cuisines left for three meals, want ErrVariety", err)
+	}
+}
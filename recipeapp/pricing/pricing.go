@@ -0,0 +1,180 @@
+package pricing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+	"strconv"
+	"strings"
+)
+
+// Price is the price of an amount of an ingredient, the amount given in a standard unit of the shopping list
+type Price struct {
+	Ingredient string  `json:"ingredient"`
+	Unit       string  `json:"unit"`
+	Per        float64 `json:"per"`
+	Price      float64 `json:"price"`
+}
+
+// NewPrice creates a price, standardizing the unit so "2.49 per 1 kg" becomes "2.49 per 1000 g"
+func NewPrice(ingredient string, per float64, unit string, price float64) (Price, error) {
+	name := shoppinglist.NormalizeIngredient(ingredient)
+	if name == "" {
+		return Price{}, errors.New("ingredient is required")
+	}
+	if per <= 0 {
+		return Price{}, errors.New("per must be positive")
+	}
+	if price < 0 {
+		return Price{}, errors.New("price must not be negative")
+	}
+	if strings.TrimSpace(unit) == "" {
+		unit = "count"
+	}
+	per, unit = shoppinglist.StandardizeUnit(per, unit)
+	return Price{Ingredient: name, Unit: unit, Per: per, Price: price}, nil
+}
+
+// Catalogue maps a normalized ingredient name and a standard unit to its price
+type Catalogue map[string]map[string]Price
+
+// NewCatalogue builds a catalogue from a list of prices
+func NewCatalogue(prices []Price) Catalogue {
+	c := Catalogue{}
+	for _, p := range prices {
+		if c[p.Ingredient] == nil {
+			c[p.Ingredient] = make(map[string]Price)
+		}
+		c[p.Ingredient][p.Unit] = p
+	}
+	return c
+}
+
+// ParseCSV reads prices with the columns ingredient, per, unit, price. Lines starting with # are comments,
+// a first line starting with "ingredient" is treated as header.
+func ParseCSV(r io.Reader) ([]Price, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 4
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "ingredient") {
+		records = records[1:]
+	}
+
+	prices := make([]Price, 0, len(records))
+	for i, record := range records {
+		per, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: invalid per: %v", i+1, err)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: invalid price: %v", i+1, err)
+		}
+		price, err := NewPrice(record[0], per, record[2], value)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %v", i+1, err)
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+// Line is a shopping list line with its estimated cost
+type Line struct {
+	shoppinglist.ShoppingItem
+	Cost   float64 `json:"cost"`
+	Priced bool    `json:"priced"`
+}
+
+// MealCost is the estimated cost of a single meal, for all portions cooked of it
+type MealCost struct {
+	IdMeal   string   `json:"idMeal"`
+	StrMeal  string   `json:"strMeal"`
+	Portions float64  `json:"portions"` // more than 1 for meals cooked for leftovers
+	Cost     float64  `json:"cost"`
+	Unpriced []string `json:"unpriced"`
+}
+
+// Estimate is the estimated cost of a whole plan
+type Estimate struct {
+	Lines    []Line     `json:"lines"`
+	Meals    []MealCost `json:"meals"`
+	Week     float64    `json:"week"`
+	Unpriced []string   `json:"unpriced"` // ingredients without a price, counted as free
+}
+
+// lookup returns the cost of an amount of an ingredient. Grams and milliliters are used
+// interchangeably when only the other one is priced, like the converter does for spoons.
+func (c Catalogue) lookup(ingredient string, amount float64, unit string) (float64, bool) {
+	units := c[shoppinglist.NormalizeIngredient(ingredient)]
+	p, ok := units[unit]
+	if !ok {
+		switch unit {
+		case "g":
+			p, ok = units["ml"]
+		case "ml":
+			p, ok = units["g"]
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+	return amount / p.Per * p.Price, true
+}
+
+// MealCost estimates the cost of a single portion of a meal
+func (c Catalogue) MealCost(meal models.Meal) MealCost {
+	converter := shoppinglist.IngredientConverter{}
+	result := MealCost{IdMeal: meal.IdMeal, StrMeal: meal.StrMeal, Portions: 1, Unpriced: []string{}}
+	for _, item := range converter.ConvertMealsToItems([]models.Meal{meal}, nil, nil) {
+		cost, ok := c.lookup(item.Ingredient, item.Amount, item.Unit)
+		if !ok {
+			result.Unpriced = append(result.Unpriced, item.Ingredient)
+		}
+		result.Cost += cost
+	}
+	result.Cost = round(result.Cost)
+	return result
+}
+
+// Cost returns the estimated cost of a portion of a meal, for the plan generator
+func (c Catalogue) Cost(meal models.Meal) float64 {
+	return c.MealCost(meal).Cost
+}
+
+// Estimate prices every line of the shopping list, every meal and the week.
+// Servings are the portions of the meals cooked for leftovers, like the plan generator the meals cost as much more.
+func (c Catalogue) Estimate(meals []models.Meal, items []shoppinglist.ShoppingItem, servings map[string]float64) Estimate {
+	result := Estimate{Lines: []Line{}, Meals: []MealCost{}, Unpriced: []string{}}
+	for _, item := range items {
+		cost, ok := c.lookup(item.Ingredient, item.Amount, item.Unit)
+		result.Lines = append(result.Lines, Line{ShoppingItem: item, Cost: round(cost), Priced: ok})
+		result.Week += cost
+		if !ok {
+			result.Unpriced = append(result.Unpriced, item.Ingredient)
+		}
+	}
+	for _, meal := range meals {
+		cost := c.MealCost(meal)
+		if portions, ok := servings[meal.IdMeal]; ok && portions > 0 {
+			cost.Portions = portions
+			cost.Cost = round(cost.Cost * portions)
+		}
+		result.Meals = append(result.Meals, cost)
+	}
+	result.Week = round(result.Week)
+	return result
+}
+
+// round rounds to cents
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,110 @@
+package pricing
+
+import (
+	"strings"
+	"testing"
+
+	"recipeapp/models"
+	"recipeapp/shoppinglist"
+)
+
+func TestNewPrice(t *testing.T) {
+	tests := []struct {
+		ingredient string
+		per        float64
+		unit       string
+		price      float64
+		want       Price
+		fails      bool
+	}{
+		{"Rice", 1, "kg", 2.49, Price{Ingredient: "rice", Unit: "g", Per: 1000, Price: 2.49}, false},
+		{" Plain  Flour ", 500, "g", 0.9, Price{Ingredient: "plain flour", Unit: "g", Per: 500, Price: 0.9}, false},
+		{"Eggs", 6, "", 1.8, Price{Ingredient: "eggs", Unit: "count", Per: 6, Price: 1.8}, false},
+		{"", 1, "kg", 1, Price{}, true},
+		{"Rice", 0, "kg", 1, Price{}, true},
+		{"Rice", 1, "kg", -1, Price{}, true},
+	}
+	for _, test := range tests {
+		price, err := NewPrice(test.ingredient, test.per, test.unit, test.price)
+		if (err != nil) != test.fails || price != test.want {
+			t.Errorf("NewPrice(%q, %v, %q, %v) = %+v, %v", test.ingredient, test.per, test.unit, test.price, price, err)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	prices, err := ParseCSV(strings.NewReader("ingredient,per,unit,price\n# from the market\nRice,1,kg,2.49\nEggs,6,,1.80\n"))
+	if err != nil || len(prices) != 2 || prices[0].Per != 1000 || prices[1].Unit != "count" {
+		t.Errorf("prices %+v (%v)", prices, err)
+	}
+	for _, input := range []string{"Rice,one,kg,2.49\n", "Rice,1,kg,free\n", "Rice,1,kg\n", ",1,kg,2\n"} {
+		if _, err := ParseCSV(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+var (
+	pasta = models.Meal{IdMeal: "1", StrMeal: "Pasta", StrIngredient1: "Spaghetti", StrMeasure1: "500g",
+		StrIngredient2: "Butter", StrMeasure2: "100g", StrIngredient3: "Saffron", StrMeasure3: "1 pinch"}
+	stew = models.Meal{IdMeal: "2", StrMeal: "Stew", StrIngredient1: "Beef", StrMeasure1: "1kg"}
+)
+
+func testCatalogue() Catalogue {
+	prices := []Price{
+		{Ingredient: "spaghetti", Unit: "g", Per: 1000, Price: 2},
+		{Ingredient: "butter", Unit: "ml", Per: 250, Price: 2.5}, // priced by volume, used by weight
+		{Ingredient: "beef", Unit: "g", Per: 1000, Price: 12},
+	}
+	return NewCatalogue(prices)
+}
+
+func TestMealCost(t *testing.T) {
+	tests := []struct {
+		meal     models.Meal
+		cost     float64
+		unpriced []string
+	}{
+		{pasta, 2, []string{"Saffron"}},
+		{stew, 12, []string{}},
+		{models.Meal{IdMeal: "3", StrMeal: "Air"}, 0, []string{}},
+	}
+	catalogue := testCatalogue()
+	for _, test := range tests {
+		cost := catalogue.MealCost(test.meal)
+		if cost.Cost != test.cost || cost.Portions != 1 || strings.Join(cost.Unpriced, ",") != strings.Join(test.unpriced, ",") {
+			t.Errorf("%s costs %+v, want %v without %v", test.meal.StrMeal, cost, test.cost, test.unpriced)
+		}
+		if catalogue.Cost(test.meal) != test.cost {
+			t.Errorf("%s: Cost %v, want %v", test.meal.StrMeal, catalogue.Cost(test.meal), test.cost)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	meals := []models.Meal{pasta, stew}
+	servings := map[string]float64{stew.IdMeal: 2} // the stew is eaten again as leftovers
+	var builder shoppinglist.Builder
+	list := builder.Build(meals, servings, nil, nil)
+
+	estimate := testCatalogue().Estimate(meals, list.Items, servings)
+	if estimate.Week != 26 {
+		t.Errorf("week costs %v, want 26", estimate.Week)
+	}
+	// the meals add up to the week like the budget of the plan generator counts them
+	sum := 0.0
+	for _, meal := range estimate.Meals {
+		sum += meal.Cost
+	}
+	if sum != estimate.Week || estimate.Meals[1].Portions != 2 || estimate.Meals[1].Cost != 24 {
+		t.Errorf("meal costs %+v, want them to add up to %v", estimate.Meals, estimate.Week)
+	}
+	if len(estimate.Unpriced) != 1 || estimate.Unpriced[0] != "Saffron" {
+		t.Errorf("unpriced %v, want Saffron", estimate.Unpriced)
+	}
+	for _, line := range estimate.Lines {
+		if line.Priced == (line.Ingredient == "Saffron") {
+			t.Errorf("line %+v priced %v", line.ShoppingItem, line.Priced)
+		}
+	}
+}
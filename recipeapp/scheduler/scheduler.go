@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// Scheduler calls Check periodically with the current time, Check runs whatever is due
+type Scheduler struct {
+	Interval time.Duration
+	Check    func(ctx context.Context, now time.Time)
+}
+
+// Run calls Check once right away, to catch up on runs missed while the server was down,
+// and then every Interval until the context is done
+func (s Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	s.Check(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Check(ctx, now)
+		}
+	}
+}
+
+// LastSlot returns the latest time at or before now that is on the weekday at the minute of the day
+func LastSlot(weekday time.Weekday, minute int, now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	back := (int(now.Weekday()) - int(weekday) + 7) % 7
+	slot := day.AddDate(0, 0, -back).Add(time.Duration(minute) * time.Minute)
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -7)
+	}
+	return slot
+}
+
+// Due reports whether a weekly slot passed since the rule was last run or changed
+func Due(weekday time.Weekday, minute int, since time.Time, now time.Time) bool {
+	return LastSlot(weekday, minute, now).After(since)
+}
+
+// NextWeek returns the Monday of the week after the given time
+func NextWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	ahead := (int(time.Monday) - int(t.Weekday()) + 7) % 7
+	if ahead == 0 {
+		ahead = 7
+	}
+	return day.AddDate(0, 0, ahead)
+}
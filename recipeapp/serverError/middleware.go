@@ -0,0 +1,45 @@
+package serverError
+
+import (
+	"log/slog"
+	"recipeapp/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Detail describes an error in a response
+type Detail struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+// Envelope is the body of every error response, e.g. {"error": {"code": "not_found", "message": "Plan not found"}}
+type Envelope struct {
+	Error Detail `json:"error"`
+}
+
+// Middleware answers the errors handlers report with c.Error. Handlers report an error and return
+// without writing a response, the last reported error is answered and all of them are logged, server errors at level error.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+		for _, err := range c.Errors {
+			e := From(err.Err)
+			level := slog.LevelInfo
+			if e.Status() >= 500 {
+				level = slog.LevelError
+			}
+			logging.FromContext(c.Request.Context()).Log(c.Request.Context(), level, "request failed",
+				"method", c.Request.Method, "route", c.FullPath(), "status", e.Status(), "code", e.Code,
+				"error", e.Error())
+		}
+		if c.Writer.Written() {
+			return
+		}
+		e := From(c.Errors.Last().Err)
+		c.JSON(e.Status(), Envelope{Error: Detail{Code: e.Code, Message: e.Message}})
+	}
+}
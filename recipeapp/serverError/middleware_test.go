@@ -0,0 +1,61 @@
+package serverError
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    Code
+		message string
+	}{
+		{"typed", New(CodeNotFound, "Plan not found"), 404, CodeNotFound, "Plan not found"},
+		{"wrapped", fmt.Errorf("lookup: %w", BadInternalApiCall), 503, CodeUnavailable, "Failed internal API call"},
+		{"untyped", errors.New("disk full"), 500, CodeInternal, "Internal server error"},
+		{"internal", Internal(errors.New("disk full")), 500, CodeInternal, "Internal server error"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Middleware())
+			router.GET("/", func(c *gin.Context) {
+				c.Error(test.err)
+			})
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+			var body Envelope
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response %s: %v", rec.Body, err)
+			}
+			if rec.Code != test.status || body.Error.Code != test.code || body.Error.Message != test.message {
+				t.Errorf("%d %+v, want %d %s %q", rec.Code, body, test.status, test.code, test.message)
+			}
+		})
+	}
+}
+
+func TestMiddlewareKeepsWrittenResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/", func(c *gin.Context) {
+		c.Error(errors.New("cache not updated"))
+		c.String(200, "ok")
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != 200 || rec.Body.String() != "ok" {
+		t.Errorf("%d %q, want the response of the handler", rec.Code, rec.Body)
+	}
+}
@@ -1,5 +1,94 @@
 package serverError
 
-import "fmt"
+import (
+	"errors"
+	"net/http"
+)
 
-var BadInternalApiCall = fmt.Errorf("Failed internal API call")
+// Code tells clients what kind of error happened, it is part of every error response
+type Code string
+
+const (
+	CodeInvalidRequest Code = "invalid_request" // the request is malformed or has invalid values
+	CodeNotFound       Code = "not_found"       // the addressed plan, recipe or setting does not exist
+	CodeNoPlan         Code = "no_plan"         // the request has no plan cookie, no plan was generated yet
+	CodeInvalidCookie  Code = "invalid_cookie"  // the plan cookie is no plan id
+	CodePlanNotFound   Code = "plan_not_found"  // the plan of the cookie does not exist (anymore)
+	CodeConflict       Code = "conflict"        // the request contradicts the current state
+	CodeTooLarge       Code = "too_large"       // an uploaded file is larger than allowed
+	CodeUnprocessable  Code = "unprocessable"   // the request is valid but cannot be fulfilled, e.g. no plan in the budget
+	CodeBadGateway     Code = "bad_gateway"     // a page to import could not be fetched
+	CodeUnavailable    Code = "unavailable"     // TheMealDB cannot be reached
+	CodeInternal       Code = "internal"        // anything else, the details are only logged
+)
+
+// statuses maps the codes to their HTTP status
+var statuses = map[Code]int{
+	CodeInvalidRequest: http.StatusBadRequest,
+	CodeNotFound:       http.StatusNotFound,
+	CodeNoPlan:         http.StatusNotFound,
+	CodeInvalidCookie:  http.StatusBadRequest,
+	CodePlanNotFound:   http.StatusNotFound,
+	CodeConflict:       http.StatusConflict,
+	CodeTooLarge:       http.StatusRequestEntityTooLarge,
+	CodeUnprocessable:  http.StatusUnprocessableEntity,
+	CodeBadGateway:     http.StatusBadGateway,
+	CodeUnavailable:    http.StatusServiceUnavailable,
+	CodeInternal:       http.StatusInternalServerError,
+}
+
+// Error is an error with a code and a message for clients, the cause is only logged
+type Error struct {
+	Code    Code
+	Message string
+	Err     error // cause, may be nil
+}
+
+// New returns an error with a code and a message for clients
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap returns an error with a code and a message for clients caused by err
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// Internal hides err from clients behind "Internal server error"
+func Internal(err error) *Error {
+	return Wrap(CodeInternal, "Internal server error", err)
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status of the error
+func (e *Error) Status() int {
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// From returns err as *Error, errors without a code are internal
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
+
+var BadInternalApiCall = New(CodeUnavailable, "Failed internal API call")
+
+var InvalidPageURL = New(CodeInvalidRequest, "Invalid page URL")
+
+var FailedPageFetch = New(CodeBadGateway, "Failed to fetch page")
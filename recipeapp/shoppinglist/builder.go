@@ -0,0 +1,25 @@
+package shoppinglist
+
+import "recipeapp/models"
+
+// List is the shopping list of some meals as lines, grouped by store section and as single items
+type List struct {
+	Lines    []string
+	Sections []SectionGroup
+	Items    []ShoppingItem
+}
+
+// Builder builds shopping lists with a new IngredientConverter each time
+type Builder struct{}
+
+// Build converts the meals, scaled by their servings, into a shopping list sorted into the sections
+// of the mapper in the given aisle order
+func (Builder) Build(meals []models.Meal, servings map[string]float64, mapper *SectionMapper, aisleOrder []Section) List {
+	converter := IngredientConverter{Servings: servings}
+	lines := converter.ConvertMeals(meals)
+	return List{
+		Lines:    lines,
+		Sections: converter.ConvertMealsBySection(meals, mapper, aisleOrder),
+		Items:    converter.ConvertMealsToItems(meals, mapper, aisleOrder),
+	}
+}
@@ -9,6 +9,8 @@ import (
 
 // IngredientConverter converts and sums ingredients from multiple recipes
 type IngredientConverter struct {
+	Servings map[string]float64 // meal id -> how often the meal is eaten, the amounts are scaled by it, 1 if missing
+
 	standardizedIngredients map[string]float64 // ingredient -> total amount in standard unit
 	ingredientUnits         map[string]string  // ingredient -> standard unit
 }
@@ -64,6 +66,21 @@ var nonStandardUnits = map[string]string{
 	"jar":     "Jar",
 }
 
+// IsKnownUnit reports whether the unit is one the converter can standardize
+func IsKnownUnit(unit string) bool {
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	_, mass := massUnits[unit]
+	_, volume := volumeUnits[unit]
+	_, other := nonStandardUnits[unit]
+	return mass || volume || other
+}
+
+// StandardizeUnit converts an amount and unit to the standard unit the shopping list uses
+func StandardizeUnit(amount float64, unit string) (float64, string) {
+	ic := IngredientConverter{}
+	return ic.convertToStandardUnit(amount, strings.ToLower(strings.TrimSpace(unit)))
+}
+
 // ConvertMeals processes multiple meals and returns the standardized shopping list as string array
 func (ic *IngredientConverter) ConvertMeals(meals []models.Meal) []string {
 	// Reset the maps for new conversion
@@ -78,6 +95,81 @@ func (ic *IngredientConverter) ConvertMeals(meals []models.Meal) []string {
 	return ic.formatShoppingList()
 }
 
+// SectionGroup is the part of a shopping list that is found in one store section
+type SectionGroup struct {
+	Section Section  `json:"section"`
+	Items   []string `json:"items"`
+
+	ingredients []string // ingredient names of the items, same order
+}
+
+// ShoppingItem is a single line of the shopping list
+type ShoppingItem struct {
+	Ingredient string  `json:"ingredient"`
+	Amount     float64 `json:"amount"`
+	Unit       string  `json:"unit"`
+	Section    Section `json:"section"`
+}
+
+// String formats the item the same way as the plain shopping list
+func (si ShoppingItem) String() string {
+	return si.Ingredient + " - " + formatAmount(si.Amount) + " " + si.Unit
+}
+
+// ConvertMealsToItems processes multiple meals and returns the shopping list items
+// sorted by the given aisle order and then alphabetically
+func (ic *IngredientConverter) ConvertMealsToItems(meals []models.Meal, mapper *SectionMapper, aisleOrder []Section) []ShoppingItem {
+	var items []ShoppingItem
+	for _, group := range ic.ConvertMealsBySection(meals, mapper, aisleOrder) {
+		for _, ingredient := range group.ingredients {
+			items = append(items, ShoppingItem{
+				Ingredient: ingredient,
+				Amount:     ic.standardizedIngredients[ingredient],
+				Unit:       ic.ingredientUnits[ingredient],
+				Section:    group.Section,
+			})
+		}
+	}
+	return items
+}
+
+// ConvertMealsBySection processes multiple meals and returns the shopping list grouped by store section.
+// The groups follow the given aisle order, sections without items are left out.
+func (ic *IngredientConverter) ConvertMealsBySection(meals []models.Meal, mapper *SectionMapper, aisleOrder []Section) []SectionGroup {
+	ic.ConvertMeals(meals)
+	return ic.groupShoppingList(mapper, aisleOrder)
+}
+
+// groupShoppingList formats the shopping list and sorts each item into its section
+func (ic *IngredientConverter) groupShoppingList(mapper *SectionMapper, aisleOrder []Section) []SectionGroup {
+	bySection := make(map[Section]*SectionGroup)
+
+	ingredients := make([]string, 0, len(ic.standardizedIngredients))
+	for ingredient := range ic.standardizedIngredients {
+		ingredients = append(ingredients, ingredient)
+	}
+	sort.Strings(ingredients)
+
+	for _, ingredient := range ingredients {
+		section := mapper.Classify(ingredient)
+		group, exists := bySection[section]
+		if !exists {
+			group = &SectionGroup{Section: section}
+			bySection[section] = group
+		}
+		group.Items = append(group.Items, ic.formatItem(ingredient))
+		group.ingredients = append(group.ingredients, ingredient)
+	}
+
+	var groups []SectionGroup
+	for _, section := range CompleteAisleOrder(aisleOrder) {
+		if group, exists := bySection[section]; exists {
+			groups = append(groups, *group)
+		}
+	}
+	return groups
+}
+
 // formatShoppingList formats the shopping list as "ingredient-measurement-unit" strings
 func (ic *IngredientConverter) formatShoppingList() []string {
 	var shoppingList []string
@@ -91,20 +183,23 @@ func (ic *IngredientConverter) formatShoppingList() []string {
 
 	// Format each ingredient
 	for _, ingredient := range ingredients {
-		amount := ic.standardizedIngredients[ingredient]
-		unit := ic.ingredientUnits[ingredient]
-
-		// Format the amount nicely
-		amountStr := formatAmount(amount)
-
-		// Create the formatted string without spaces
-		item := ingredient + " - " + amountStr + " " + unit
-		shoppingList = append(shoppingList, item)
+		shoppingList = append(shoppingList, ic.formatItem(ingredient))
 	}
 
 	return shoppingList
 }
 
+// formatItem formats a single ingredient as "ingredient - amount unit"
+func (ic *IngredientConverter) formatItem(ingredient string) string {
+	amount := ic.standardizedIngredients[ingredient]
+	unit := ic.ingredientUnits[ingredient]
+
+	// Format the amount nicely
+	amountStr := formatAmount(amount)
+
+	return ingredient + " - " + amountStr + " " + unit
+}
+
 // formatAmount formats the amount nicely (removes .00 if whole number)
 func formatAmount(amount float64) string {
 	if amount == float64(int64(amount)) {
@@ -129,8 +224,16 @@ func (ic *IngredientConverter) processMeal(meal models.Meal) {
 			continue
 		}
 
-		ic.processIngredient(ingredient, measure)
+		ic.processIngredient(ingredient, measure, ic.servings(meal))
+	}
+}
+
+// servings returns the factor the ingredients of a meal are scaled by
+func (ic *IngredientConverter) servings(meal models.Meal) float64 {
+	if servings, ok := ic.Servings[meal.IdMeal]; ok && servings > 0 {
+		return servings
 	}
+	return 1
 }
 
 // extractIngredients extracts all non-empty ingredients from a meal
@@ -173,8 +276,8 @@ func (ic *IngredientConverter) extractMeasures(meal models.Meal) []string {
 	return relevantMeasures
 }
 
-// processIngredient processes a single ingredient and adds it to the total
-func (ic *IngredientConverter) processIngredient(ingredient, measure string) {
+// processIngredient processes a single ingredient and adds it, scaled by the servings, to the total
+func (ic *IngredientConverter) processIngredient(ingredient, measure string, servings float64) {
 	// Parse the measure using the shoppinglist utility
 	amount, unit, err := SplitLeadingNumberDecimal(measure)
 	if err != nil {
@@ -193,7 +296,7 @@ func (ic *IngredientConverter) processIngredient(ingredient, measure string) {
 	standardizedAmount, standardUnit := ic.convertToStandardUnit(amount, normalizedUnit)
 
 	// Update the total for this ingredient
-	ic.standardizedIngredients[ingredient] += standardizedAmount
+	ic.standardizedIngredients[ingredient] += standardizedAmount * servings
 	ic.ingredientUnits[ingredient] = standardUnit
 }
 
@@ -0,0 +1,400 @@
+package shoppinglist
+
+import (
+	"strings"
+)
+
+// Section is the store section an ingredient is shelved in
+type Section string
+
+const (
+	SectionProduce Section = "produce"
+	SectionDairy   Section = "dairy"
+	SectionMeat    Section = "meat"
+	SectionSpices  Section = "spices"
+	SectionCanned  Section = "canned"
+	SectionBakery  Section = "bakery"
+	SectionFrozen  Section = "frozen"
+	SectionOther   Section = "other"
+)
+
+// DefaultAisleOrder is the walking order used when a store has no aisle order of its own
+var DefaultAisleOrder = []Section{
+	SectionProduce,
+	SectionBakery,
+	SectionMeat,
+	SectionDairy,
+	SectionCanned,
+	SectionSpices,
+	SectionFrozen,
+	SectionOther,
+}
+
+// defaultSections maps normalized ingredient names taken from TheMealDB's ingredient list to a section
+var defaultSections = map[string]Section{
+	// Produce
+	"apple":              SectionProduce,
+	"apples":             SectionProduce,
+	"asparagus":          SectionProduce,
+	"aubergine":          SectionProduce,
+	"avocado":            SectionProduce,
+	"baby plum tomatoes": SectionProduce,
+	"baby spinach":       SectionProduce,
+	"banana":             SectionProduce,
+	"basil":              SectionProduce,
+	"basil leaves":       SectionProduce,
+	"bean sprouts":       SectionProduce,
+	"beetroot":           SectionProduce,
+	"bok choy":           SectionProduce,
+	"broccoli":           SectionProduce,
+	"brussels sprouts":   SectionProduce,
+	"butternut squash":   SectionProduce,
+	"cabbage":            SectionProduce,
+	"carrot":             SectionProduce,
+	"carrots":            SectionProduce,
+	"cauliflower":        SectionProduce,
+	"celeriac":           SectionProduce,
+	"celery":             SectionProduce,
+	"cherry tomatoes":    SectionProduce,
+	"chives":             SectionProduce,
+	"cilantro":           SectionProduce,
+	"coriander":          SectionProduce,
+	"courgettes":         SectionProduce,
+	"cucumber":           SectionProduce,
+	"dill":               SectionProduce,
+	"fennel":             SectionProduce,
+	"garlic":             SectionProduce,
+	"garlic clove":       SectionProduce,
+	"ginger":             SectionProduce,
+	"green beans":        SectionProduce,
+	"green chilli":       SectionProduce,
+	"green pepper":       SectionProduce,
+	"kale":               SectionProduce,
+	"leek":               SectionProduce,
+	"lemon":              SectionProduce,
+	"lemons":             SectionProduce,
+	"lettuce":            SectionProduce,
+	"lime":               SectionProduce,
+	"mint":               SectionProduce,
+	"mushrooms":          SectionProduce,
+	"onion":              SectionProduce,
+	"onions":             SectionProduce,
+	"parsley":            SectionProduce,
+	"parsnips":           SectionProduce,
+	"potatoes":           SectionProduce,
+	"red chilli":         SectionProduce,
+	"red onions":         SectionProduce,
+	"red pepper":         SectionProduce,
+	"rosemary":           SectionProduce,
+	"sage":               SectionProduce,
+	"shallots":           SectionProduce,
+	"spinach":            SectionProduce,
+	"spring onions":      SectionProduce,
+	"sweet potatoes":     SectionProduce,
+	"thyme":              SectionProduce,
+	"tomato":             SectionProduce,
+	"tomatoes":           SectionProduce,
+	"yellow pepper":      SectionProduce,
+	"zucchini":           SectionProduce,
+
+	// Dairy
+	"butter":                  SectionDairy,
+	"cheddar cheese":          SectionDairy,
+	"cream":                   SectionDairy,
+	"creme fraiche":           SectionDairy,
+	"double cream":            SectionDairy,
+	"egg":                     SectionDairy,
+	"egg white":               SectionDairy,
+	"egg yolks":               SectionDairy,
+	"eggs":                    SectionDairy,
+	"feta":                    SectionDairy,
+	"greek yogurt":            SectionDairy,
+	"gruyère":                 SectionDairy,
+	"heavy cream":             SectionDairy,
+	"milk":                    SectionDairy,
+	"mozzarella":              SectionDairy,
+	"parmesan":                SectionDairy,
+	"parmesan cheese":         SectionDairy,
+	"ricotta":                 SectionDairy,
+	"single cream":            SectionDairy,
+	"sour cream":              SectionDairy,
+	"unsalted butter":         SectionDairy,
+	"yogurt":                  SectionDairy,
+	"cream cheese":            SectionDairy,
+	"mascarpone":              SectionDairy,
+	"paneer":                  SectionDairy,
+	"goats cheese":            SectionDairy,
+	"salted butter":           SectionDairy,
+	"whole milk":              SectionDairy,
+	"full fat yogurt":         SectionDairy,
+	"shredded mexican cheese": SectionDairy,
+
+	// Meat and fish
+	"bacon":           SectionMeat,
+	"beef":            SectionMeat,
+	"beef brisket":    SectionMeat,
+	"chicken":         SectionMeat,
+	"chicken breast":  SectionMeat,
+	"chicken breasts": SectionMeat,
+	"chicken legs":    SectionMeat,
+	"chicken thighs":  SectionMeat,
+	"chorizo":         SectionMeat,
+	"cod":             SectionMeat,
+	"duck":            SectionMeat,
+	"ground beef":     SectionMeat,
+	"ham":             SectionMeat,
+	"king prawns":     SectionMeat,
+	"lamb":            SectionMeat,
+	"lamb mince":      SectionMeat,
+	"minced beef":     SectionMeat,
+	"mussels":         SectionMeat,
+	"pork":            SectionMeat,
+	"pork chops":      SectionMeat,
+	"prawns":          SectionMeat,
+	"salmon":          SectionMeat,
+	"sausages":        SectionMeat,
+	"smoked haddock":  SectionMeat,
+	"tuna":            SectionMeat,
+	"turkey mince":    SectionMeat,
+	"white fish":      SectionMeat,
+	"stewing beef":    SectionMeat,
+	"beef fillet":     SectionMeat,
+	"pancetta":        SectionMeat,
+	"prosciutto":      SectionMeat,
+
+	// Herbs, spices and seasoning
+	"allspice":           SectionSpices,
+	"bay leaf":           SectionSpices,
+	"bay leaves":         SectionSpices,
+	"black pepper":       SectionSpices,
+	"cajun":              SectionSpices,
+	"cardamom":           SectionSpices,
+	"cayenne pepper":     SectionSpices,
+	"chili powder":       SectionSpices,
+	"chilli powder":      SectionSpices,
+	"cinnamon":           SectionSpices,
+	"cinnamon stick":     SectionSpices,
+	"cloves":             SectionSpices,
+	"coriander seeds":    SectionSpices,
+	"cumin":              SectionSpices,
+	"cumin seeds":        SectionSpices,
+	"curry powder":       SectionSpices,
+	"dried oregano":      SectionSpices,
+	"fennel seeds":       SectionSpices,
+	"garam masala":       SectionSpices,
+	"ground cumin":       SectionSpices,
+	"mustard seeds":      SectionSpices,
+	"nutmeg":             SectionSpices,
+	"oregano":            SectionSpices,
+	"paprika":            SectionSpices,
+	"pepper":             SectionSpices,
+	"red chilli flakes":  SectionSpices,
+	"saffron":            SectionSpices,
+	"salt":               SectionSpices,
+	"sea salt":           SectionSpices,
+	"smoked paprika":     SectionSpices,
+	"star anise":         SectionSpices,
+	"turmeric":           SectionSpices,
+	"turmeric powder":    SectionSpices,
+	"vanilla extract":    SectionSpices,
+	"italian seasoning":  SectionSpices,
+	"chinese five spice": SectionSpices,
+
+	// Canned and dry goods
+	"baked beans":          SectionCanned,
+	"basmati rice":         SectionCanned,
+	"black beans":          SectionCanned,
+	"brown sugar":          SectionCanned,
+	"caster sugar":         SectionCanned,
+	"chicken stock":        SectionCanned,
+	"chickpeas":            SectionCanned,
+	"coconut milk":         SectionCanned,
+	"chopped tomatoes":     SectionCanned,
+	"cornstarch":           SectionCanned,
+	"dijon mustard":        SectionCanned,
+	"fish sauce":           SectionCanned,
+	"flour":                SectionCanned,
+	"honey":                SectionCanned,
+	"kidney beans":         SectionCanned,
+	"lentils":              SectionCanned,
+	"olive oil":            SectionCanned,
+	"oyster sauce":         SectionCanned,
+	"peanut butter":        SectionCanned,
+	"plain flour":          SectionCanned,
+	"rice":                 SectionCanned,
+	"rice vinegar":         SectionCanned,
+	"self-raising flour":   SectionCanned,
+	"soy sauce":            SectionCanned,
+	"spaghetti":            SectionCanned,
+	"sugar":                SectionCanned,
+	"sunflower oil":        SectionCanned,
+	"tomato puree":         SectionCanned,
+	"tomato ketchup":       SectionCanned,
+	"vegetable oil":        SectionCanned,
+	"vegetable stock":      SectionCanned,
+	"beef stock":           SectionCanned,
+	"white wine vinegar":   SectionCanned,
+	"worcestershire sauce": SectionCanned,
+	"penne rigate":         SectionCanned,
+	"egg noodles":          SectionCanned,
+	"rice noodles":         SectionCanned,
+	"lasagne sheets":       SectionCanned,
+	"sesame seed oil":      SectionCanned,
+	"sesame seeds":         SectionCanned,
+	"peanuts":              SectionCanned,
+	"cashew nuts":          SectionCanned,
+	"almonds":              SectionCanned,
+	"raisins":              SectionCanned,
+	"red wine":             SectionCanned,
+	"white wine":           SectionCanned,
+
+	// Bakery
+	"bread":           SectionBakery,
+	"breadcrumbs":     SectionBakery,
+	"bun":             SectionBakery,
+	"burger buns":     SectionBakery,
+	"ciabatta":        SectionBakery,
+	"flour tortilla":  SectionBakery,
+	"naan bread":      SectionBakery,
+	"pita bread":      SectionBakery,
+	"sourdough bread": SectionBakery,
+	"tortillas":       SectionBakery,
+	"baguette":        SectionBakery,
+
+	// Frozen
+	"frozen peas":          SectionFrozen,
+	"peas":                 SectionFrozen,
+	"ice cream":            SectionFrozen,
+	"puff pastry":          SectionFrozen,
+	"shortcrust pastry":    SectionFrozen,
+	"filo pastry":          SectionFrozen,
+	"frozen mixed berries": SectionFrozen,
+	"sweetcorn":            SectionFrozen,
+}
+
+// sectionKeywords is used for ingredients that are not in the mapping,
+// matching any word of the ingredient name. Checked in order.
+var sectionKeywords = []struct {
+	keyword string
+	section Section
+}{
+	{"frozen", SectionFrozen},
+	{"stock", SectionCanned},
+	{"sauce", SectionCanned},
+	{"oil", SectionCanned},
+	{"vinegar", SectionCanned},
+	{"flour", SectionCanned},
+	{"sugar", SectionCanned},
+	{"beans", SectionCanned},
+	{"pasta", SectionCanned},
+	{"noodles", SectionCanned},
+	{"rice", SectionCanned},
+	{"tinned", SectionCanned},
+	{"canned", SectionCanned},
+	{"bread", SectionBakery},
+	{"buns", SectionBakery},
+	{"tortilla", SectionBakery},
+	{"cheese", SectionDairy},
+	{"cream", SectionDairy},
+	{"milk", SectionDairy},
+	{"butter", SectionDairy},
+	{"yogurt", SectionDairy},
+	{"chicken", SectionMeat},
+	{"beef", SectionMeat},
+	{"pork", SectionMeat},
+	{"lamb", SectionMeat},
+	{"sausage", SectionMeat},
+	{"fish", SectionMeat},
+	{"prawns", SectionMeat},
+	{"turkey", SectionMeat},
+	{"veal", SectionMeat},
+	{"venison", SectionMeat},
+	{"duck", SectionMeat},
+	{"mince", SectionMeat},
+	{"minced", SectionMeat},
+	// generic words of spices come after the meats, "ground turkey" is meat and "ground ginger" a spice
+	{"powder", SectionSpices},
+	{"seeds", SectionSpices},
+	{"ground", SectionSpices},
+	{"dried", SectionSpices},
+	{"garlic", SectionProduce},
+	{"onion", SectionProduce},
+	{"onions", SectionProduce},
+	{"tomatoes", SectionProduce},
+	{"potatoes", SectionProduce},
+	{"mushrooms", SectionProduce},
+	{"pepper", SectionProduce},
+	{"chilli", SectionProduce},
+	{"leaves", SectionProduce},
+}
+
+// SectionMapper classifies ingredients into store sections
+type SectionMapper struct {
+	overrides map[string]Section // normalized ingredient -> section, takes precedence over the defaults
+}
+
+// NewSectionMapper creates a SectionMapper using the given overrides on top of the default mapping
+func NewSectionMapper(overrides map[string]Section) *SectionMapper {
+	normalized := make(map[string]Section, len(overrides))
+	for ingredient, section := range overrides {
+		normalized[NormalizeIngredient(ingredient)] = section
+	}
+	return &SectionMapper{overrides: normalized}
+}
+
+// Classify returns the section an ingredient belongs to, SectionOther if it is unknown
+func (sm *SectionMapper) Classify(ingredient string) Section {
+	name := NormalizeIngredient(ingredient)
+	if sm != nil {
+		if section, exists := sm.overrides[name]; exists {
+			return section
+		}
+	}
+	if section, exists := defaultSections[name]; exists {
+		return section
+	}
+	// Fall back to keywords in the ingredient name
+	words := strings.Fields(name)
+	for _, kw := range sectionKeywords {
+		for _, word := range words {
+			if word == kw.keyword {
+				return kw.section
+			}
+		}
+	}
+	return SectionOther
+}
+
+// NormalizeIngredient lowercases an ingredient name and collapses its whitespace
+func NormalizeIngredient(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// ParseSection parses a section name, returning false if it is not a known section
+func ParseSection(s string) (Section, bool) {
+	section := Section(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range DefaultAisleOrder {
+		if section == known {
+			return section, true
+		}
+	}
+	return "", false
+}
+
+// CompleteAisleOrder returns the given order with all missing sections appended in default order
+func CompleteAisleOrder(order []Section) []Section {
+	complete := make([]Section, 0, len(DefaultAisleOrder))
+	seen := make(map[Section]bool)
+	for _, section := range order {
+		if !seen[section] {
+			seen[section] = true
+			complete = append(complete, section)
+		}
+	}
+	for _, section := range DefaultAisleOrder {
+		if !seen[section] {
+			complete = append(complete, section)
+		}
+	}
+	return complete
+}
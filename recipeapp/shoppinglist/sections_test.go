@@ -0,0 +1,64 @@
+package shoppinglist
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		ingredient string
+		section    Section
+	}{
+		{"Garlic", SectionProduce},
+		{"  Cherry   Tomatoes ", SectionProduce},
+		{"Ground Beef", SectionMeat},
+		{"Ground Turkey", SectionMeat},
+		{"Ground Pork", SectionMeat},
+		{"Ground Lamb", SectionMeat},
+		{"Turkey Mince", SectionMeat},
+		{"Ground Ginger", SectionSpices},
+		{"Ground Cumin", SectionSpices},
+		{"Garlic Powder", SectionSpices},
+		{"Dried Basil", SectionSpices},
+		{"Goats Cheese", SectionDairy},
+		{"Blue Cheese", SectionDairy},
+		{"Frozen Spinach", SectionFrozen},
+		{"Hoisin Sauce", SectionCanned},
+		{"Chicken Stock", SectionCanned},
+		{"Naan Bread", SectionBakery},
+		{"Fish Fillets", SectionMeat},
+		{"Red Onions", SectionProduce},
+		{"Star Fruit", SectionOther},
+	}
+	mapper := NewSectionMapper(nil)
+	for _, test := range tests {
+		if section := mapper.Classify(test.ingredient); section != test.section {
+			t.Errorf("%q in %s, want %s", test.ingredient, section, test.section)
+		}
+	}
+}
+
+func TestClassifyOverrides(t *testing.T) {
+	mapper := NewSectionMapper(map[string]Section{"Leek ": SectionFrozen})
+	if section := mapper.Classify("leek"); section != SectionFrozen {
+		t.Errorf("overridden leek in %s", section)
+	}
+	if section := mapper.Classify("garlic"); section != SectionProduce {
+		t.Errorf("garlic in %s next to an override", section)
+	}
+}
+
+func TestCompleteAisleOrder(t *testing.T) {
+	order := CompleteAisleOrder([]Section{SectionDairy, SectionProduce, SectionDairy})
+	if len(order) != len(DefaultAisleOrder) || order[0] != SectionDairy || order[1] != SectionProduce ||
+		order[2] != SectionBakery {
+		t.Errorf("aisle order %v", order)
+	}
+}
+
+func TestParseSection(t *testing.T) {
+	if section, ok := ParseSection(" Frozen "); !ok || section != SectionFrozen {
+		t.Errorf("parsed %q, %v", section, ok)
+	}
+	if _, ok := ParseSection("garden"); ok {
+		t.Error("parsed an unknown section")
+	}
+}
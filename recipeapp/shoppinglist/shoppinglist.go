@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // - The numeric value is returned as a decimal (float64).
@@ -131,3 +132,36 @@ func splitNumerDenom(frac string) (int64, int64, error) {
 	}
 	return numer, denom, nil
 }
+
+// ValidateMeasure checks that a measure can be read by the shopping list converter.
+// Measures without a leading amount ("pinch", "to taste") are valid and count as one unit,
+// but an amount that is present has to parse and be positive.
+func ValidateMeasure(measure string) error {
+	measure = strings.TrimSpace(measure)
+	if measure == "" {
+		return nil
+	}
+	if !startsWithAmount(measure) {
+		return nil
+	}
+	amount, unit, err := SplitLeadingNumberDecimal(measure)
+	if err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive: %q", measure)
+	}
+	if len(unit) > 1 && (unit[0] == '.' || unit[0] == ',') && unicode.IsDigit(rune(unit[1])) {
+		return fmt.Errorf("decimal amounts are not supported, use fractions like 1 1/2: %q", measure)
+	}
+	if unit != "" && unicode.IsDigit(rune(unit[0])) {
+		return fmt.Errorf("invalid amount: %q", measure)
+	}
+	return nil
+}
+
+// startsWithAmount reports whether a measure starts with a sign or a digit
+func startsWithAmount(measure string) bool {
+	c := measure[0]
+	return c == '+' || c == '-' || (c >= '0' && c <= '9')
+}
@@ -6,7 +6,11 @@ import useSWR, { useSWRConfig } from 'swr'
 import useSWRMutation from 'swr/mutation'
 import React from "react";
 
-const fetcher = (url: string) => fetch(url).then((res) => res.json());
+// Error responses, e.g. before the first plan was generated, are thrown so SWR reports them as error
+const fetcher = (url: string) => fetch(url).then((res) => {
+  if (!res.ok) throw new Error(res.statusText);
+  return res.json();
+});
 
 type Recipe =
   {